@@ -0,0 +1,178 @@
+package alert
+
+import (
+	"log"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+const (
+	maxRepeatAlerts    = 5                // Default number of reminders an escalation sends
+	maxRetries         = 5                // Attempts to send an alert before dead-lettering it
+	stallAlertInterval = 60 * time.Minute // Time interval for repeating unacknowledged 'Stall' alerts
+	// checkInterval is how often ended silences and due escalations are
+	// checked, and firing alerts resent to sinks that expire them.
+	checkInterval = 30 * time.Second
+)
+
+// Watch tracks the condition of every alert from alertChan and sends the
+// ones that fire or resolve a condition to the notifiers they are routed to.
+func Watch(alertChan <-chan Alert, cfg settings.Config, notifiers *Registry) {
+	checkRoutes(cfg)
+	notifiers.replay()
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	process := func(a Alert) {
+		if d, send := notifiers.admit(a); send {
+			notifiers.deliver(d.notifiers, d.alert)
+		}
+	}
+
+	for {
+		select {
+		case a := <-alertChan:
+			if a.AlertType == None {
+				log.Println(a.Message)
+				continue
+			}
+			if a.Time.IsZero() {
+				a.Time = time.Now()
+			}
+			a.Message = render(notifiers.templates, a)
+			process(a)
+		case now := <-ticker.C:
+			reports, held := notifiers.endSilences(now)
+			for _, report := range reports {
+				report.Time = now
+				report.Message = render(notifiers.templates, report)
+				log.Println(report.Message)
+				dispatch(notifiers, report)
+			}
+			// Conditions that fired during a silence and haven't cleared are
+			// raised now, or they would go unreported.
+			for _, a := range held {
+				process(a)
+			}
+			for _, d := range notifiers.due(now) {
+				notifiers.deliver(d.notifiers, d.alert)
+			}
+			notifiers.refresh()
+		}
+	}
+}
+
+// dispatch queues an alert for each of its notifiers.
+func dispatch(notifiers *Registry, a Alert) {
+	notifiers.deliver(notifiers.For(a), a)
+}
+
+func Nil(message string) Alert {
+	return Alert{AlertType: None, Severity: Info, Message: message}
+}
+
+func Missed(missed int, check int, validatorMoniker string) Alert {
+	return rendered(Alert{AlertType: Miss, Severity: Critical, Kind: KindMissed, Template: "missed", Data: Data{Name: validatorMoniker, Missed: missed, Window: check}})
+}
+
+func Cleared(signed int, check int, validatorMoniker string) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindMissed, Template: "cleared", Data: Data{Name: validatorMoniker, Signed: signed, Window: check}})
+}
+
+func Signed(signed int, check int, validatorMoniker string) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindMissed, Template: "signed", Data: Data{Name: validatorMoniker, Signed: signed, Window: check}})
+}
+
+func NilVoted(nilVotes int, check int, validatorMoniker string) Alert {
+	return rendered(Alert{AlertType: NilVote, Severity: Warning, Kind: KindNilVote, Template: "nil_voted", Data: Data{Name: validatorMoniker, NilVotes: nilVotes, Window: check}})
+}
+
+func NilVotesCleared(nilVotes int, check int, validatorMoniker string) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindNilVote, Template: "nil_votes_cleared", Data: Data{Name: validatorMoniker, NilVotes: nilVotes, Window: check}})
+}
+
+func NoRpc(ChainId string) Alert {
+	return rendered(Alert{AlertType: RpcError, Severity: Warning, Kind: KindRpc, Template: "no_rpc", Data: Data{ChainId: ChainId}})
+}
+
+func RpcRecovered(ChainId string) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindRpc, Template: "rpc_recovered", Data: Data{ChainId: ChainId}})
+}
+
+func InvalidHeight(ChainId string) Alert {
+	return rendered(Alert{AlertType: Error, Severity: Warning, Kind: KindHeight, Template: "invalid_height", Data: Data{ChainId: ChainId}})
+}
+
+func Stalled(blocktime time.Time, ChainId string) Alert {
+	return rendered(Alert{AlertType: Stall, Severity: Critical, Kind: KindStall, Template: "stalled", Data: Data{ChainId: ChainId, Time: blocktime}})
+}
+
+func StallCleared(ChainId string) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindStall, Template: "stall_cleared", Data: Data{ChainId: ChainId}})
+}
+
+func SignerDown(name string) Alert {
+	return rendered(Alert{AlertType: RpcError, Severity: Warning, Kind: KindSigner, Template: "signer_down", Data: Data{Name: name}})
+}
+
+func SignerRecovered(name string) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindSigner, Template: "signer_recovered", Data: Data{Name: name}})
+}
+
+func SignerError(name string, errors int64) Alert {
+	return rendered(Alert{AlertType: Error, Severity: Warning, Kind: KindSignerErrors, Template: "signer_error", Data: Data{Name: name, Errors: errors}})
+}
+
+func SignerStalled(blocktime time.Time, name string) Alert {
+	return rendered(Alert{AlertType: Stall, Severity: Critical, Kind: KindSigner, Template: "signer_stalled", Data: Data{Name: name, Time: blocktime}})
+}
+
+func PeerUnreachable(peer string, intervals int) Alert {
+	return rendered(Alert{AlertType: PeerDown, Severity: Warning, Kind: KindPeer, Subject: peer, Template: "peer_unreachable", Data: Data{Name: peer, Intervals: intervals}})
+}
+
+// PeerUnhealthy is a peer that answers its health check but reports itself
+// unhealthy.
+func PeerUnhealthy(peer string, intervals int) Alert {
+	return rendered(Alert{AlertType: PeerDown, Severity: Warning, Kind: KindPeer, Subject: peer, Template: "peer_unhealthy", Data: Data{Name: peer, Intervals: intervals}})
+}
+
+func PeerRecovered(peer string) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindPeer, Subject: peer, Template: "peer_recovered", Data: Data{Name: peer}})
+}
+
+func Jailed(name string, until time.Time) Alert {
+	return rendered(Alert{AlertType: Jail, Severity: Critical, Kind: KindJail, Template: "jailed", Data: Data{Name: name, Time: until}})
+}
+
+func Unjailed(name string) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindJail, Template: "unjailed", Data: Data{Name: name}})
+}
+
+func Tombstoned(name string) Alert {
+	return rendered(Alert{AlertType: Jail, Severity: Critical, Kind: KindTombstone, Template: "tombstoned", Data: Data{Name: name}})
+}
+
+func SlashingWindow(name string, percent int, missed int64, maxMissed int64, timeToJail time.Duration) Alert {
+	severity := Warning
+	if percent >= 75 {
+		severity = Critical
+	}
+	return rendered(Alert{AlertType: Miss, Severity: severity, Kind: KindSlashing, Template: "slashing_window", Data: Data{
+		Name: name, Percent: percent, MissedCounter: missed, MaxMissed: maxMissed, Remaining: maxMissed - missed, Duration: timeToJail,
+	}})
+}
+
+func SlashingWindowCleared(name string, missed int64, maxMissed int64) Alert {
+	return rendered(Alert{AlertType: Clear, Severity: Info, Kind: KindSlashing, Template: "slashing_window_cleared", Data: Data{Name: name, MissedCounter: missed, MaxMissed: maxMissed}})
+}
+
+// SilenceEnded reports the alerts a silence dropped.
+func SilenceEnded(s Silence, suppressed []Alert) Alert {
+	a := Alert{AlertType: Report, Severity: Info, Kind: KindSilence, Subject: s.Id, Template: "silence_ended", Data: Data{Name: s.Reason, Count: len(suppressed), Summary: summarise(suppressed)}}
+	if len(s.Networks) == 1 {
+		a.Network = s.Networks[0]
+	}
+	return rendered(a)
+}
@@ -0,0 +1,66 @@
+package alert
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// alertmanagerExpiry is how long Alertmanager keeps a firing alert it
+// doesn't hear about again. Firing conditions are resent every
+// checkInterval, so it only resolves them on its own once penpal stops.
+const alertmanagerExpiry = 4 * checkInterval
+
+func (am *alertmanagerNotifier) Name() string {
+	return "alertmanager"
+}
+
+// Send posts the alert in the Alertmanager v2 API format. A Clear alert
+// resolves the condition by reposting the labels of the alert it resolves
+// with endsAt set. Reports would never resolve, so they are skipped.
+func (am *alertmanagerNotifier) Send(ctx context.Context, a Alert) error {
+	if a.AlertType == Report {
+		return nil
+	}
+	return postJSON(ctx, am.client, "POST", strings.TrimSuffix(am.cfg.Url, "/")+"/api/v2/alerts", nil, []alertmanagerAlert{alertmanagerPayload(a, time.Now())})
+}
+
+func alertmanagerPayload(a Alert, now time.Time) alertmanagerAlert {
+	fired, endsAt := a, now.Add(alertmanagerExpiry).UTC()
+	if a.AlertType == Clear {
+		if a.Resolves != nil {
+			fired = *a.Resolves
+		}
+		endsAt = a.Time.UTC()
+	}
+	return alertmanagerAlert{
+		Labels:      alertmanagerLabels(fired),
+		Annotations: map[string]string{"summary": strings.TrimSpace(a.Message)},
+		StartsAt:    fired.Time.UTC(),
+		EndsAt:      &endsAt,
+	}
+}
+
+// alertmanagerLabels derives the labels of an alert from its condition, so
+// resending or resolving it matches the alert Alertmanager already has, even
+// after a restart.
+func alertmanagerLabels(a Alert) map[string]string {
+	labels := map[string]string{
+		"alertname": "penpal_" + a.Kind,
+		"network":   a.Network,
+		"chain_id":  a.ChainId,
+		"type":      a.AlertType.String(),
+		"severity":  a.Severity.String(),
+	}
+	if a.Subject != "" {
+		labels["subject"] = a.Subject
+	}
+	return labels
+}
+
+// expires reports whether a notifier drops alerts it isn't sent again, so
+// firing conditions must be refreshed on it.
+func expires(n Notifier) bool {
+	_, ok := unwrap(n).(*alertmanagerNotifier)
+	return ok
+}
@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestAlertmanagerRefreshAndResolve(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		posted []alertmanagerAlert
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts []alertmanagerAlert
+		if r.URL.Path != "/api/v2/alerts" || json.NewDecoder(r.Body).Decode(&alerts) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		posted = append(posted, alerts...)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var cfg settings.Config
+	cfg.Notifiers.Alertmanager = settings.Alertmanager{Url: srv.URL + "/"}
+	registry, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	missed := Missed(5, 20, "val")
+	missed.Network, missed.ChainId, missed.Time = "mainnet", "chain-1", time.Now()
+	d, _ := registry.admit(missed)
+	registry.deliver(d.notifiers, d.alert)
+	registry.sending.Wait()
+	registry.refresh()
+	registry.sending.Wait()
+
+	// The cleared condition resolves with the same labels after a restart.
+	restarted, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	restarted.Restore(registry.Snapshot())
+	cleared := Cleared(18, 20, "val")
+	cleared.Network, cleared.ChainId, cleared.Time = "mainnet", "chain-1", time.Now()
+	d, send := restarted.admit(cleared)
+	if !send {
+		t.Fatalf("expected the clear to be sent")
+	}
+	restarted.deliver(d.notifiers, d.alert)
+	restarted.sending.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(posted) != 3 {
+		t.Fatalf("expected the alert posted, refreshed and resolved, got %d posts", len(posted))
+	}
+	fired, refreshed, resolved := posted[0], posted[1], posted[2]
+	if fired.EndsAt == nil || !fired.EndsAt.After(time.Now()) || refreshed.EndsAt == nil || refreshed.EndsAt.Before(*fired.EndsAt) {
+		t.Fatalf("expected firing alerts to expire in the future, got %v and %v", fired.EndsAt, refreshed.EndsAt)
+	}
+	if resolved.EndsAt == nil || resolved.EndsAt.After(time.Now()) {
+		t.Fatalf("expected the resolve to end the alert, got %v", resolved.EndsAt)
+	}
+	for _, a := range []alertmanagerAlert{refreshed, resolved} {
+		if !reflect.DeepEqual(a.Labels, fired.Labels) || !a.StartsAt.Equal(fired.StartsAt) {
+			t.Fatalf("expected labels %v from %s, got %v from %s", fired.Labels, fired.StartsAt, a.Labels, a.StartsAt)
+		}
+	}
+	if fired.Labels["severity"] != "critical" || fired.Labels["type"] != "miss" {
+		t.Fatalf("unexpected labels %v", fired.Labels)
+	}
+}
+
+func TestAlertmanagerRefreshCoalesced(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		posts int
+	)
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		mu.Lock()
+		posts++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var cfg settings.Config
+	cfg.Notifiers.Alertmanager = settings.Alertmanager{Url: srv.URL}
+	registry, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	missed := Missed(5, 20, "val")
+	missed.Network, missed.Time = "mainnet", time.Now()
+	d, _ := registry.admit(missed)
+	registry.deliver(d.notifiers, d.alert)
+	// The sink hangs on the first alert while the ticks go by.
+	for i := 0; i < 10; i++ {
+		registry.refresh()
+	}
+	w := registry.worker(d.notifiers[0])
+	w.mu.Lock()
+	queued := len(w.queue)
+	w.mu.Unlock()
+	close(unblock)
+	registry.sending.Wait()
+
+	if queued != 2 {
+		t.Fatalf("expected the alert and one refresh queued, got %d", queued)
+	}
+	registry.refresh()
+	registry.sending.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if posts != 3 {
+		t.Fatalf("expected the alert, one refresh and a refresh queued once that was sent, got %d posts", posts)
+	}
+}
@@ -0,0 +1,60 @@
+package alert
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func (s *slackNotifier) Name() string {
+	return "slack"
+}
+
+func (s *slackNotifier) Send(ctx context.Context, a Alert) error {
+	message := slackEscape(strings.TrimSpace(a.Message))
+	meta := a.Severity.String() + " · " + a.AlertType.String()
+	if a.Network != "" {
+		meta = a.Network + " · " + meta
+	}
+	return postJSON(ctx, s.client, "POST", s.cfg.Webhook, nil, slackMessage{
+		Text: message,
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: message}},
+			{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: slackEscape(meta)}}},
+		},
+	})
+}
+
+// slackEscaper escapes the characters Slack reads as markup, so a moniker
+// like "<validator>" isn't taken for a link or mention.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func slackEscape(text string) string {
+	return slackEscaper.Replace(text)
+}
+
+func (m *matrixNotifier) Name() string {
+	return "matrix"
+}
+
+func (m *matrixNotifier) Send(ctx context.Context, a Alert) error {
+	endpoint := strings.TrimSuffix(m.cfg.Homeserver, "/") + "/_matrix/client/v3/rooms/" + url.PathEscape(m.cfg.Room) + "/send/m.room.message/" + url.PathEscape(matrixTxn(a))
+	header := map[string]string{"Authorization": "Bearer " + m.cfg.Token}
+	return postJSON(ctx, m.client, "PUT", endpoint, header, matrixMessage{MsgType: "m.text", Body: strings.TrimSpace(a.Message)})
+}
+
+// matrixTxn is the transaction id of an alert. It is the same on every
+// attempt to send the alert, so the homeserver drops a retry of a message it
+// already got.
+func matrixTxn(a Alert) string {
+	return a.Id() + "/" + a.AlertType.String() + "/" + strconv.FormatInt(a.Time.UnixNano(), 10)
+}
+
+func (m *mattermostNotifier) Name() string {
+	return "mattermost"
+}
+
+func (m *mattermostNotifier) Send(ctx context.Context, a Alert) error {
+	return postJSON(ctx, m.client, "POST", m.cfg.Webhook, nil, mattermostMessage{Username: "penpal", Channel: m.cfg.Channel, Text: strings.TrimSpace(a.Message)})
+}
@@ -0,0 +1,95 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestSlackBlocks(t *testing.T) {
+	var msg slackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+	}))
+	defer srv.Close()
+
+	s := &slackNotifier{client: srv.Client(), cfg: settings.Slack{Webhook: srv.URL}}
+	a := Missed(5, 20, "<val> & co")
+	a.Network = "mainnet"
+	if err := s.Send(context.Background(), a); err != nil {
+		t.Fatalf("send returned error: %v", err)
+	}
+	if !strings.Contains(msg.Text, "&lt;val&gt; &amp; co missed 5 of 20") || len(msg.Blocks) != 2 {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	section, context := msg.Blocks[0], msg.Blocks[1]
+	if section.Type != "section" || section.Text == nil || section.Text.Type != "mrkdwn" || section.Text.Text != msg.Text {
+		t.Fatalf("unexpected section block %+v", section)
+	}
+	if context.Type != "context" || len(context.Elements) != 1 || context.Elements[0].Text != "mainnet · critical · miss" {
+		t.Fatalf("unexpected context block %+v", context)
+	}
+}
+
+func TestMatrixSend(t *testing.T) {
+	var (
+		paths []string
+		msg   matrixMessage
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("unexpected request %s with %q", r.Method, r.Header.Get("Authorization"))
+		}
+		paths = append(paths, r.URL.EscapedPath())
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		_, _ = w.Write([]byte(`{"event_id": "$1"}`))
+	}))
+	defer srv.Close()
+
+	m := &matrixNotifier{client: srv.Client(), cfg: settings.Matrix{Homeserver: srv.URL + "/", Token: "token", Room: "!room:matrix.org"}}
+	noRpc := NoRpc("chain-1")
+	noRpc.Network, noRpc.Time = "mainnet", time.Now()
+	recovered := RpcRecovered("chain-1")
+	recovered.Network, recovered.Time = "mainnet", noRpc.Time.Add(time.Minute)
+	// The second send is a retry of the first.
+	for _, a := range []Alert{noRpc, noRpc, recovered} {
+		if err := m.Send(context.Background(), a); err != nil {
+			t.Fatalf("send returned error: %v", err)
+		}
+	}
+	prefix := "/_matrix/client/v3/rooms/%21room:matrix.org/send/m.room.message/"
+	if len(paths) != 3 || !strings.HasPrefix(paths[0], prefix) || strings.Contains(strings.TrimPrefix(paths[0], prefix), "/") {
+		t.Fatalf("unexpected paths %q", paths)
+	}
+	if paths[0] != paths[1] || paths[1] == paths[2] {
+		t.Fatalf("expected a retry to reuse its transaction id and another alert to get a new one, got %q", paths)
+	}
+	if msg.MsgType != "m.text" || !strings.Contains(msg.Body, "chain-1") {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+}
+
+func TestMattermostSend(t *testing.T) {
+	var msg mattermostMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+	}))
+	defer srv.Close()
+
+	m := &mattermostNotifier{client: srv.Client(), cfg: settings.Mattermost{Webhook: srv.URL, Channel: "alerts"}}
+	if err := m.Send(context.Background(), Stalled(time.Now(), "chain-1")); err != nil {
+		t.Fatalf("send returned error: %v", err)
+	}
+	if msg.Username != "penpal" || msg.Channel != "alerts" || !strings.Contains(msg.Text, "chain-1") || strings.HasSuffix(msg.Text, "\n") {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+}
@@ -0,0 +1,207 @@
+package alert
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// events are kinds of alerts about something that happened rather than a
+// condition that holds until it clears. They are sent every time.
+var events = map[string]bool{
+	KindHeight:       true,
+	KindSignerErrors: true,
+	KindSilence:      true,
+}
+
+// Id identifies the condition an alert is about by its network, kind and
+// subject, so the alerts raising and clearing it share one id and land on
+// the same incident.
+func (a Alert) Id() string {
+	id := "penpal/" + a.Network + "/" + a.Kind
+	if a.Subject != "" {
+		id += "/" + a.Subject
+	}
+	return id
+}
+
+// admit moves the condition an alert is about through its lifecycle and
+// returns the alert as it should be sent with the notifiers it goes to, or
+// false if nothing should be.
+//
+// A condition raised for the first time fires, unless a silence holds it
+// back as pending until the silence ends; a downgrading silence fires it as
+// info instead. Raising a firing condition again is dropped unless it got
+// worse, or a stall went unanswered for stallAlertInterval. A clear resolves
+// the condition and is only sent if the condition fired, to every notifier
+// the condition was sent to rather than where the clear itself would be
+// routed, so the incidents it opened are closed.
+func (r *Registry) admit(a Alert) (delivery, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	s := r.silencing(a, now)
+	if events[a.Kind] {
+		if s == nil {
+			return delivery{alert: a, notifiers: r.route(a)}, true
+		}
+		if !s.Downgrade {
+			s.suppressed = append(s.suppressed, a)
+			log.Printf("Skipping alert '%s' as it is silenced.", a.Message)
+			return delivery{}, false
+		}
+		a = r.downgrade(a, s)
+		return delivery{alert: a, notifiers: r.route(a)}, true
+	}
+
+	id := a.Id()
+	c, exists := r.conditions[id]
+	if a.AlertType == Clear {
+		if !exists {
+			return delivery{}, false
+		}
+		delete(r.conditions, id)
+		log.Println("Alert", id, "is", Resolved)
+		resolves := c.alert
+		a.Resolves = &resolves
+		notifiers := c.notifiers
+		if len(notifiers) == 0 {
+			// Restored from a snapshot that didn't record them.
+			notifiers = r.route(c.alert)
+		}
+		return delivery{alert: a, notifiers: notifiers}, c.state == Firing
+	}
+	if !exists {
+		c = &condition{alert: a, state: Pending, severity: a.Severity, since: now}
+		r.conditions[id] = c
+	}
+	if c.state == Firing {
+		stalled := a.Kind == KindStall && now.Sub(c.lastSent) >= stallAlertInterval
+		if c.acked || !(stalled || worse(a, c)) {
+			return delivery{}, false
+		}
+	}
+	if s != nil && !s.Downgrade {
+		// A condition counts once towards what a silence suppressed, however
+		// often it is raised while pending.
+		if !exists || c.state == Firing {
+			s.suppressed = append(s.suppressed, a)
+			log.Printf("Skipping alert '%s' as it is silenced.", a.Message)
+		}
+		if c.state == Pending {
+			c.alert, c.severity = a, a.Severity
+		}
+		return delivery{}, false
+	}
+
+	c.severity = a.Severity
+	if s != nil {
+		a = r.downgrade(a, s)
+	}
+	c.alert, c.lastSent = a, now
+	if c.state == Pending {
+		c.state, c.firedAt = Firing, now
+		log.Println("Alert", id, "is", Firing)
+	}
+	notifiers := r.route(a)
+	c.notifiers = appendUnique(c.notifiers, notifiers...)
+	return delivery{alert: a, notifiers: notifiers}, true
+}
+
+// refresh queues the alerts of firing conditions again for the notifiers
+// that expire alerts they stop hearing about.
+func (r *Registry) refresh() {
+	var refreshes []delivery
+	r.mu.Lock()
+	for _, c := range r.conditions {
+		if c.state != Firing {
+			continue
+		}
+		var notifiers []Notifier
+		for _, n := range c.notifiers {
+			if expires(n) {
+				notifiers = append(notifiers, n)
+			}
+		}
+		if len(notifiers) > 0 {
+			refreshes = append(refreshes, delivery{alert: c.alert, notifiers: notifiers})
+		}
+	}
+	r.mu.Unlock()
+	for _, d := range refreshes {
+		for _, n := range d.notifiers {
+			r.worker(n).refresh(d.alert)
+		}
+	}
+}
+
+// downgrade sends an alert as info with a note naming the silence.
+func (r *Registry) downgrade(a Alert, s *silence) Alert {
+	a.Severity = Info
+	a.Note = "🔕 silenced (" + s.Reason + "): "
+	a.Message = render(r.templates, a)
+	return a
+}
+
+// worse reports whether an alert raised for a firing condition is more
+// severe than the one sent, or uses more of the slashing window.
+func worse(a Alert, c *condition) bool {
+	return a.Severity > c.severity || a.Data.Percent > c.alert.Data.Percent
+}
+
+// Ack acknowledges the firing conditions of a network, or all of them when
+// network is empty, so they stop repeating until they clear. It returns the
+// alerts it acknowledged.
+func (r *Registry) Ack(network string) []Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var acked []Alert
+	for _, c := range r.conditions {
+		if c.state == Firing && (network == "" || c.alert.Network == network) && !c.acked {
+			c.acked = true
+			acked = append(acked, c.alert)
+		}
+	}
+	sortByTime(acked)
+	return acked
+}
+
+// Active returns the alerts of the firing conditions.
+func (r *Registry) Active() []Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var active []Alert
+	for _, c := range r.conditions {
+		if c.state == Firing {
+			active = append(active, c.alert)
+		}
+	}
+	sortByTime(active)
+	return active
+}
+
+// Conditions returns the pending and firing conditions, oldest first.
+func (r *Registry) Conditions() []Condition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conditions := make([]Condition, 0, len(r.conditions))
+	for id, c := range r.conditions {
+		conditions = append(conditions, Condition{
+			Id:       id,
+			State:    c.state,
+			Network:  c.alert.Network,
+			Type:     c.alert.AlertType.String(),
+			Severity: c.severity.String(),
+			Message:  c.alert.Message,
+			Since:    c.since,
+			Acked:    c.acked,
+		})
+	}
+	sort.Slice(conditions, func(i, j int) bool {
+		if !conditions[i].Since.Equal(conditions[j].Since) {
+			return conditions[i].Since.Before(conditions[j].Since)
+		}
+		return conditions[i].Id < conditions[j].Id
+	})
+	return conditions
+}
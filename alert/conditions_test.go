@@ -0,0 +1,64 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestConditionLifecycle(t *testing.T) {
+	r, err := NewRegistry(settings.Config{}, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	raise := func(a Alert) bool {
+		a.Network = "mainnet"
+		_, send := r.admit(a)
+		return send
+	}
+
+	if raise(StallCleared("chain-1")) {
+		t.Fatalf("expected a clear for a condition that never fired to be dropped")
+	}
+	if !raise(Stalled(time.Now().Add(-time.Hour), "chain-1")) {
+		t.Fatalf("expected the first stall to fire")
+	}
+	if raise(Stalled(time.Now().Add(-2*time.Hour), "chain-1")) {
+		t.Fatalf("expected a stall with another block time to be the same condition")
+	}
+	conditions := r.Conditions()
+	if len(conditions) != 1 || conditions[0].Id != "penpal/mainnet/stall" || conditions[0].State != Firing {
+		t.Fatalf("unexpected conditions %+v", conditions)
+	}
+	if !raise(StallCleared("chain-1")) || raise(StallCleared("chain-1")) {
+		t.Fatalf("expected the stall to resolve once")
+	}
+	if len(r.Conditions()) != 0 {
+		t.Fatalf("expected no conditions after resolving")
+	}
+
+	if !raise(SlashingWindow("mainnet", 25, 125, 500, time.Hour)) || raise(SlashingWindow("mainnet", 25, 130, 500, time.Hour)) {
+		t.Fatalf("expected the slashing window alert to fire once per level")
+	}
+	if !raise(SlashingWindow("mainnet", 50, 250, 500, time.Hour)) {
+		t.Fatalf("expected a higher slashing level to be sent")
+	}
+	if !raise(InvalidHeight("chain-1")) || !raise(InvalidHeight("chain-1")) {
+		t.Fatalf("expected events to be sent every time")
+	}
+
+	r.Mute("mainnet", time.Now().Add(time.Hour))
+	if raise(Missed(5, 20, "mainnet")) {
+		t.Fatalf("expected a muted alert to be held back")
+	}
+	conditions = r.Conditions()
+	if len(conditions) != 2 || conditions[1].State != Pending {
+		t.Fatalf("expected the missed blocks condition to be pending, got %+v", conditions)
+	}
+	r.Unmute("mainnet")
+	_, held := r.endSilences(time.Now())
+	if len(held) != 1 || !raise(held[0]) || r.Conditions()[1].State != Firing {
+		t.Fatalf("expected the pending condition to fire once unmuted")
+	}
+}
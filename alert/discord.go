@@ -0,0 +1,60 @@
+package alert
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Embed colours by severity.
+const (
+	colorInfo     = 0x2ecc71
+	colorWarning  = 0xf1c40f
+	colorCritical = 0xe74c3c
+)
+
+func (d *discordNotifier) Name() string {
+	return "discord"
+}
+
+func (d *discordNotifier) Send(ctx context.Context, a Alert) error {
+	msg := discordMessage{Username: "Alert-", Content: a.Message}
+	if d.cfg.Embeds {
+		msg.Content = ""
+		msg.Embeds = []discordEmbed{discordEmbedFor(a)}
+	}
+	return postJSON(ctx, d.client, "POST", d.cfg.Webhook, nil, msg)
+}
+
+func discordEmbedFor(a Alert) discordEmbed {
+	embed := discordEmbed{
+		Title:       headline(a),
+		Description: strings.TrimSpace(a.Message),
+		Url:         a.Data.Link,
+		Color:       colorInfo,
+		Timestamp:   a.Time.UTC().Format(time.RFC3339),
+	}
+	switch a.Severity {
+	case Critical:
+		embed.Color = colorCritical
+	case Warning:
+		embed.Color = colorWarning
+	}
+	if a.ChainId != "" {
+		embed.Fields = append(embed.Fields, discordField{Name: "Chain", Value: a.ChainId, Inline: true})
+	}
+	if a.Data.Height > 0 {
+		height := strconv.FormatInt(a.Data.Height, 10)
+		if a.Data.Link != "" {
+			height = "[" + height + "](" + a.Data.Link + ")"
+		}
+		embed.Fields = append(embed.Fields, discordField{Name: "Height", Value: height, Inline: true})
+	}
+	if a.Data.Window > 0 {
+		embed.Fields = append(embed.Fields,
+			discordField{Name: "Missed", Value: strconv.Itoa(a.Data.Missed), Inline: true},
+			discordField{Name: "Window", Value: strconv.Itoa(a.Data.Window), Inline: true})
+	}
+	return embed
+}
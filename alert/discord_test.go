@@ -0,0 +1,43 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestDiscordEmbed(t *testing.T) {
+	var msg discordMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	a := NilVoted(3, 20, "val")
+	a.Network, a.ChainId = "mainnet", "chain-1"
+	a.Data.Height, a.Data.Missed, a.Data.Window = 1234, 1, 20
+	a.Data.Link = "https://explorer.test/blocks/1234"
+	a.Time = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	d := &discordNotifier{client: srv.Client(), cfg: settings.Discord{Webhook: srv.URL, Embeds: true}}
+	if err := d.Send(context.Background(), a); err != nil {
+		t.Fatalf("send returned error: %v", err)
+	}
+
+	if msg.Content != "" || len(msg.Embeds) != 1 {
+		t.Fatalf("expected a single embed and no content, got %+v", msg)
+	}
+	embed := msg.Embeds[0]
+	if embed.Title != "WARNING nil_vote · mainnet" || embed.Color != colorWarning || embed.Timestamp != "2024-01-02T03:04:05Z" {
+		t.Fatalf("unexpected embed %+v", embed)
+	}
+	if len(embed.Fields) != 4 || embed.Fields[1].Value != "[1234](https://explorer.test/blocks/1234)" || embed.Fields[3].Value != "20" {
+		t.Fatalf("unexpected fields %+v", embed.Fields)
+	}
+}
@@ -0,0 +1,139 @@
+package alert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+const smtpTimeout = 10 * time.Second
+
+// smtpRoots verifies the mail server's certificate, the system roots when nil.
+var smtpRoots *x509.CertPool
+
+func (e *emailNotifier) Name() string {
+	return "email"
+}
+
+// Send mails the alert.
+func (e *emailNotifier) Send(ctx context.Context, a Alert) error {
+	return sendEmail(ctx, e.cfg, emailMessage{Subject: emailSubject(a), Body: emailLine(a) + "\n"})
+}
+
+func (e *emailNotifier) digestEvery() time.Duration {
+	return time.Duration(e.cfg.DigestMins) * time.Minute
+}
+
+// digests reports whether an alert waits for the digest rather than being
+// mailed on its own: with digests enabled, every alert but critical ones.
+func (e *emailNotifier) digests(a Alert) bool {
+	return e.cfg.DigestMins > 0 && a.Severity != Critical
+}
+
+// SendDigest mails the batched alerts in one email.
+func (e *emailNotifier) SendDigest(ctx context.Context, alerts []Alert) error {
+	return sendEmail(ctx, e.cfg, digestMessage(alerts))
+}
+
+func emailSubject(a Alert) string {
+	subject := "[penpal] " + a.Severity.String() + " " + a.AlertType.String()
+	if a.Network != "" {
+		subject += " - " + a.Network
+	}
+	return subject
+}
+
+func emailLine(a Alert) string {
+	line := a.Time.UTC().Format(time.RFC3339)
+	if a.Network != "" {
+		line += " " + a.Network
+	}
+	return line + " [" + a.Severity.String() + " " + a.AlertType.String() + "] " + strings.TrimSpace(a.Message)
+}
+
+func digestMessage(alerts []Alert) emailMessage {
+	lines := make([]string, len(alerts))
+	for i, a := range alerts {
+		lines[i] = emailLine(a)
+	}
+	return emailMessage{
+		Subject: "[penpal] digest - " + strconv.Itoa(len(alerts)) + " alerts",
+		Body:    strings.Join(lines, "\n") + "\n",
+	}
+}
+
+func sendEmail(ctx context.Context, e settings.Email, msg emailMessage) error {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	tlsConfig := &tls.Config{ServerName: e.Host, RootCAs: smtpRoots, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if e.TLS == "implicit" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("smtp dial failed: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(3 * smtpTimeout))
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer c.Close()
+
+	if e.TLS == "" || e.TLS == "starttls" {
+		if err = c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+	if e.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err = c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err = c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(emailBody(e, msg)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func emailBody(e settings.Email, msg emailMessage) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + e.From + "\r\n")
+	sb.WriteString("To: " + strings.Join(e.To, ", ") + "\r\n")
+	sb.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	sb.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
@@ -0,0 +1,190 @@
+package alert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestEmailSubject(t *testing.T) {
+	a := Missed(5, 20, "val")
+	if subject := emailSubject(a); subject != "[penpal] critical miss" {
+		t.Fatalf("unexpected subject %q", subject)
+	}
+	a.Network = "mainnet"
+	if subject := emailSubject(a); subject != "[penpal] critical miss - mainnet" {
+		t.Fatalf("unexpected subject %q", subject)
+	}
+}
+
+func TestEmailBody(t *testing.T) {
+	cfg := settings.Email{From: "penpal@example.com", To: []string{"a@example.com", "b@example.com"}}
+	body := string(emailBody(cfg, emailMessage{Subject: "[penpal] ⏰ stall", Body: "first\nsecond\n"}))
+	headers, text, ok := strings.Cut(body, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("expected a blank line after the headers, got %q", body)
+	}
+	for _, header := range []string{
+		"From: penpal@example.com",
+		"To: a@example.com, b@example.com",
+		"Subject: =?utf-8?q?[penpal]_=E2=8F=B0_stall?=",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	} {
+		if !strings.Contains(headers+"\r\n", header+"\r\n") {
+			t.Fatalf("expected header %q in %q", header, headers)
+		}
+	}
+	if text != "first\r\nsecond\r\n" {
+		t.Fatalf("expected CRLF line endings, got %q", text)
+	}
+}
+
+func TestEmailDigests(t *testing.T) {
+	e := &emailNotifier{cfg: settings.Email{DigestMins: 10}}
+	if e.digestEvery() != 10*time.Minute {
+		t.Fatalf("unexpected digest interval %s", e.digestEvery())
+	}
+	if e.digests(Stalled(time.Now(), "chain-1")) {
+		t.Fatalf("expected critical alerts mailed at once")
+	}
+	if !e.digests(NoRpc("chain-1")) || !e.digests(Signed(20, 20, "val")) {
+		t.Fatalf("expected other alerts to wait for the digest")
+	}
+	if (&emailNotifier{}).digests(NoRpc("chain-1")) {
+		t.Fatalf("expected no digest when disabled")
+	}
+
+	a, b := NoRpc("chain-1"), Signed(20, 20, "val")
+	a.Network, b.Network = "mainnet", "testnet"
+	msg := digestMessage([]Alert{a, b})
+	lines := strings.Split(strings.TrimSuffix(msg.Body, "\n"), "\n")
+	if msg.Subject != "[penpal] digest - 2 alerts" || len(lines) != 2 {
+		t.Fatalf("unexpected digest %+v", msg)
+	}
+	if !strings.Contains(lines[0], " mainnet [warning rpc_error] ") || !strings.Contains(lines[1], " testnet [info clear] ") {
+		t.Fatalf("unexpected digest lines %q", lines)
+	}
+}
+
+// fakeSmtp answers one SMTP session on l, sending the message it receives on
+// the channel. It doesn't offer STARTTLS and refuses it when asked.
+func fakeSmtp(l net.Listener) <-chan string {
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		_ = tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch verb := strings.ToUpper(strings.Fields(line + " ")[0]); verb {
+			case "EHLO":
+				_ = tp.PrintfLine("250-localhost")
+				_ = tp.PrintfLine("250 8BITMIME")
+			case "DATA":
+				_ = tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotBytes()
+				_ = tp.PrintfLine("250 ok")
+				received <- string(data)
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				return
+			case "STARTTLS":
+				_ = tp.PrintfLine("502 not supported")
+			default:
+				_ = tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return received
+}
+
+func emailTo(l net.Listener, mode string) settings.Email {
+	return settings.Email{Host: "127.0.0.1", Port: l.Addr().(*net.TCPAddr).Port, TLS: mode, From: "penpal@example.com", To: []string{"ops@example.com"}}
+}
+
+func TestEmailTls(t *testing.T) {
+	send := func(t *testing.T, l net.Listener, mode string) (string, error) {
+		received := fakeSmtp(l)
+		e := &emailNotifier{cfg: emailTo(l, mode)}
+		a := Stalled(time.Now(), "chain-1")
+		a.Network = "mainnet"
+		if err := e.Send(context.Background(), a); err != nil {
+			return "", err
+		}
+		select {
+		case data := <-received:
+			return data, nil
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no message received")
+			return "", nil
+		}
+	}
+
+	t.Run("none", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen failed: %v", err)
+		}
+		defer l.Close()
+		data, err := send(t, l, "none")
+		if err != nil {
+			t.Fatalf("send returned error: %v", err)
+		}
+		if !strings.Contains(data, "Subject: [penpal] critical stall - mainnet\n") {
+			t.Fatalf("unexpected message %q", data)
+		}
+	})
+
+	t.Run("starttls", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen failed: %v", err)
+		}
+		defer l.Close()
+		if _, err = send(t, l, "starttls"); err == nil || !strings.Contains(err.Error(), "starttls failed") {
+			t.Fatalf("expected the mail not to go out without STARTTLS, got %v", err)
+		}
+	})
+
+	t.Run("implicit", func(t *testing.T) {
+		// Borrow httptest's certificate, which is valid for 127.0.0.1.
+		srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+		srv.StartTLS()
+		config, roots := srv.TLS.Clone(), x509.NewCertPool()
+		roots.AddCert(srv.Certificate())
+		srv.Close()
+		previous := smtpRoots
+		smtpRoots = roots
+		t.Cleanup(func() { smtpRoots = previous })
+
+		l, err := tls.Listen("tcp", "127.0.0.1:0", config)
+		if err != nil {
+			t.Fatalf("listen failed: %v", err)
+		}
+		defer l.Close()
+		data, err := send(t, l, "implicit")
+		if err != nil {
+			t.Fatalf("send returned error: %v", err)
+		}
+		if !strings.Contains(data, "To: ops@example.com\n") {
+			t.Fatalf("unexpected message %q", data)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+// escalationFor returns the first escalation policy matching an alert.
+func escalationFor(cfg settings.Config, a Alert) *settings.Escalation {
+	for i, e := range cfg.Notifiers.Escalations {
+		if len(e.Networks) > 0 && !matchesAny(e.Networks, a.Network) {
+			continue
+		}
+		if matchesAny(e.Severities, a.Severity.String()) && matchesAny(e.Types, a.AlertType.String()) {
+			return &cfg.Notifiers.Escalations[i]
+		}
+	}
+	return nil
+}
+
+// due returns the reminders and escalations of firing conditions that are
+// due. Acknowledged and silenced conditions are left alone, and once a
+// condition has escalated its reminders go to the escalation sinks too, as
+// does its clear.
+func (r *Registry) due(now time.Time) []delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []delivery
+	for _, c := range r.conditions {
+		if c.state != Firing || c.acked {
+			continue
+		}
+		policy := escalationFor(r.cfg, c.alert)
+		if policy == nil || r.silencing(c.alert, now) != nil {
+			continue
+		}
+		a := c.alert
+		if policy.EscalateMins > 0 && !c.escalated && now.Sub(c.firedAt) >= time.Duration(policy.EscalateMins)*time.Minute {
+			c.escalated = true
+			c.lastSent = now
+			a.Note = "⏫ unacknowledged for " + now.Sub(c.firedAt).Round(time.Minute).String() + ": "
+			a.Message = render(r.templates, a)
+			tier := r.fromSinks(policy.Escalate)
+			c.notifiers = appendUnique(c.notifiers, tier...)
+			deliveries = append(deliveries, delivery{alert: a, notifiers: tier})
+			continue
+		}
+		maxRepeats := policy.MaxRepeats
+		if maxRepeats == 0 {
+			maxRepeats = maxRepeatAlerts
+		}
+		if policy.RepeatMins > 0 && c.repeats < maxRepeats && now.Sub(c.lastSent) >= time.Duration(policy.RepeatMins)*time.Minute {
+			c.repeats++
+			c.lastSent = now
+			a.Note = "🔁 reminder " + strconv.Itoa(c.repeats) + "/" + strconv.Itoa(maxRepeats) + ": "
+			a.Message = render(r.templates, a)
+			notifiers := r.route(a)
+			if c.escalated {
+				notifiers = appendUnique(notifiers, r.fromSinks(policy.Escalate)...)
+			}
+			deliveries = append(deliveries, delivery{alert: a, notifiers: notifiers})
+		}
+	}
+	sortDeliveries(deliveries)
+	return deliveries
+}
+
+func appendUnique(notifiers []Notifier, more ...Notifier) []Notifier {
+	for _, n := range more {
+		if !hasNotifier(notifiers, n) {
+			notifiers = append(notifiers, n)
+		}
+	}
+	return notifiers
+}
+
+func hasNotifier(notifiers []Notifier, n Notifier) bool {
+	for _, existing := range notifiers {
+		if existing == n {
+			return true
+		}
+	}
+	return false
+}
+
+func sortDeliveries(deliveries []delivery) {
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].alert.Time.Before(deliveries[j].alert.Time) })
+}
@@ -0,0 +1,65 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestEscalation(t *testing.T) {
+	var cfg settings.Config
+	cfg.Notifiers.Slack.Webhook = "https://slack.test/hook"
+	escalation := settings.Escalation{Severities: []string{"critical"}, RepeatMins: 10, MaxRepeats: 2, EscalateMins: 30}
+	escalation.Escalate.Discord.Webhook = "https://discord.test/oncall"
+	cfg.Notifiers.Escalations = []settings.Escalation{escalation}
+	r, err := NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+
+	missed := Missed(5, 20, "val")
+	missed.Network = "mainnet"
+	rpcDown := NoRpc("chain-1")
+	rpcDown.Network = "mainnet"
+	r.admit(missed)
+	r.admit(rpcDown)
+	start := time.Now()
+
+	names := func(d delivery) string {
+		var s string
+		for _, n := range d.notifiers {
+			s += n.Name() + " "
+		}
+		return s
+	}
+	if due := r.due(start.Add(5 * time.Minute)); len(due) != 0 {
+		t.Fatalf("expected nothing due yet, got %d", len(due))
+	}
+	due := r.due(start.Add(11 * time.Minute))
+	if len(due) != 1 || names(due[0]) != "slack " || due[0].alert.Message != "🔁 reminder 1/2: ❌ val missed 5 of 20 recent blocks" {
+		t.Fatalf("expected a first reminder to slack, got %+v", due)
+	}
+	if due = r.due(start.Add(22 * time.Minute)); len(due) != 1 {
+		t.Fatalf("expected a second reminder, got %d", len(due))
+	}
+	due = r.due(start.Add(31 * time.Minute))
+	if len(due) != 1 || names(due[0]) != "discord " || due[0].alert.Message != "⏫ unacknowledged for 31m0s: ❌ val missed 5 of 20 recent blocks" {
+		t.Fatalf("expected an escalation to discord, got %+v", due)
+	}
+	if due = r.due(start.Add(45 * time.Minute)); len(due) != 0 {
+		t.Fatalf("expected reminders to stop after max repeats, got %d", len(due))
+	}
+
+	r.Ack("mainnet")
+	r.admit(missed)
+	cleared := Cleared(20, 20, "val")
+	cleared.Network = "mainnet"
+	if resolved, send := r.admit(cleared); !send || names(resolved) != "slack discord " {
+		t.Fatalf("expected the clear to reach the escalation tier too, got %+v", resolved)
+	}
+	r.admit(missed)
+	if due = r.due(time.Now().Add(11 * time.Minute)); len(due) != 1 {
+		t.Fatalf("expected reminders again for a new occurrence after clearing, got %d", len(due))
+	}
+}
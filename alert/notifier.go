@@ -0,0 +1,277 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+// NewRegistry checks the message templates and silences in cfg and returns a
+// registry for its notifiers.
+func NewRegistry(cfg settings.Config, client *http.Client) (*Registry, error) {
+	templates, err := parseTemplates(cfg.Notifiers.Templates)
+	if err != nil {
+		return nil, err
+	}
+	for _, sinks := range cfg.AllSinks() {
+		for _, overrides := range sinkTemplates(sinks) {
+			if _, err := parseTemplates(cfg.Notifiers.Templates, overrides); err != nil {
+				return nil, err
+			}
+		}
+	}
+	r := &Registry{
+		cfg:        cfg,
+		client:     client,
+		templates:  templates,
+		built:      make(map[string]Notifier),
+		keys:       make(map[Notifier]string),
+		conditions: make(map[string]*condition),
+		workers:    make(map[Notifier]*worker),
+	}
+	if cfg.Notifiers.Outbox != "" {
+		if r.outbox, err = openOutbox(cfg.Notifiers.Outbox); err != nil {
+			return nil, fmt.Errorf("outbox: %w", err)
+		}
+	}
+	now := time.Now()
+	for i, s := range cfg.Silences {
+		silence, err := NewSilence(s, now)
+		if err != nil {
+			return nil, fmt.Errorf("silence %d: %w", i+1, err)
+		}
+		silence.Id = "config-" + strconv.Itoa(i+1)
+		r.addSilence(silence)
+	}
+	return r, nil
+}
+
+// Register adds a notifier that receives every alert, in addition to the
+// ones configured in settings.
+func (r *Registry) Register(n Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.custom = append(r.custom, n)
+	r.keys[n] = "custom/" + n.Name()
+}
+
+// For returns the notifiers an alert is routed to, each at most once even if
+// several routes send to the same sink.
+func (r *Registry) For(a Alert) []Notifier {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.route(a)
+}
+
+func (r *Registry) route(a Alert) []Notifier {
+	var notifiers []Notifier
+	seen := make(map[Notifier]bool)
+	add := func(n Notifier) {
+		if !seen[n] {
+			seen[n] = true
+			notifiers = append(notifiers, n)
+		}
+	}
+	for _, sinks := range destinations(r.cfg, a) {
+		for _, n := range r.fromSinks(sinks) {
+			add(n)
+		}
+	}
+	for _, n := range r.custom {
+		add(n)
+	}
+	return notifiers
+}
+
+// byKey returns the notifiers of every configured sink and the registered
+// ones by their outbox key.
+func (r *Registry) byKey() map[string]Notifier {
+	for _, sinks := range r.cfg.AllSinks() {
+		r.fromSinks(sinks)
+	}
+	notifiers := make(map[string]Notifier, len(r.keys))
+	for n, key := range r.keys {
+		notifiers[key] = n
+	}
+	return notifiers
+}
+
+// fromSinks returns a notifier for every sink configured in s. Identical sink
+// settings share one notifier, so their state (digests, firing alerts) is
+// kept in one place.
+func (r *Registry) fromSinks(s settings.Sinks) []Notifier {
+	var notifiers []Notifier
+	if s.Telegram.Key != "" {
+		notifiers = append(notifiers, r.cached("telegram", s.Telegram, s.Telegram.Templates, func() Notifier {
+			return &telegramNotifier{client: r.client, cfg: s.Telegram}
+		}))
+	}
+	if s.Discord.Webhook != "" {
+		notifiers = append(notifiers, r.cached("discord", s.Discord, s.Discord.Templates, func() Notifier {
+			return &discordNotifier{client: r.client, cfg: s.Discord}
+		}))
+	}
+	if s.PagerDuty.RoutingKey != "" {
+		notifiers = append(notifiers, r.cached("pagerduty", s.PagerDuty, s.PagerDuty.Templates, func() Notifier {
+			return &pagerdutyNotifier{client: r.client, cfg: s.PagerDuty}
+		}))
+	}
+	if s.Alertmanager.Url != "" {
+		notifiers = append(notifiers, r.cached("alertmanager", s.Alertmanager, s.Alertmanager.Templates, func() Notifier {
+			return &alertmanagerNotifier{client: r.client, cfg: s.Alertmanager}
+		}))
+	}
+	if s.Opsgenie.Key != "" {
+		notifiers = append(notifiers, r.cached("opsgenie", s.Opsgenie, s.Opsgenie.Templates, func() Notifier {
+			return &opsgenieNotifier{client: r.client, cfg: s.Opsgenie}
+		}))
+	}
+	if s.Slack.Webhook != "" {
+		notifiers = append(notifiers, r.cached("slack", s.Slack, s.Slack.Templates, func() Notifier {
+			return &slackNotifier{client: r.client, cfg: s.Slack}
+		}))
+	}
+	if s.Matrix.Homeserver != "" {
+		notifiers = append(notifiers, r.cached("matrix", s.Matrix, s.Matrix.Templates, func() Notifier {
+			return &matrixNotifier{client: r.client, cfg: s.Matrix}
+		}))
+	}
+	if s.Mattermost.Webhook != "" {
+		notifiers = append(notifiers, r.cached("mattermost", s.Mattermost, s.Mattermost.Templates, func() Notifier {
+			return &mattermostNotifier{client: r.client, cfg: s.Mattermost}
+		}))
+	}
+	if s.Email.Host != "" {
+		notifiers = append(notifiers, r.cached("email", s.Email, s.Email.Templates, func() Notifier {
+			return &emailNotifier{cfg: s.Email}
+		}))
+	}
+	return notifiers
+}
+
+// cached returns the notifier built for a sink's settings, building it the
+// first time. Sinks with their own templates are wrapped to render them.
+func (r *Registry) cached(kind string, cfg interface{}, overrides map[string]string, build func() Notifier) Notifier {
+	key, err := json.Marshal(cfg)
+	if err != nil {
+		return build()
+	}
+	id := kind + string(key)
+	n, exists := r.built[id]
+	if !exists {
+		n = build()
+		if len(overrides) > 0 {
+			if templates, err := parseTemplates(r.cfg.Notifiers.Templates, overrides); err == nil {
+				n = &templated{Notifier: n, templates: templates}
+			}
+		}
+		r.built[id] = n
+		// Sink settings hold secrets, so the outbox only stores a hash.
+		sum := sha256.Sum256([]byte(id))
+		r.keys[n] = kind + "/" + hex.EncodeToString(sum[:8])
+	}
+	return n
+}
+
+func (t *templated) Send(ctx context.Context, a Alert) error {
+	a.Message = render(t.templates, a)
+	return t.Notifier.Send(ctx, a)
+}
+
+// unwrap returns the sink behind a notifier wrapped for its templates.
+func unwrap(n Notifier) Notifier {
+	if t, ok := n.(*templated); ok {
+		return t.Notifier
+	}
+	return n
+}
+
+// sinkTemplates returns the template overrides of each sink in s.
+func sinkTemplates(s settings.Sinks) []map[string]string {
+	return []map[string]string{
+		s.Telegram.Templates, s.Discord.Templates, s.PagerDuty.Templates,
+		s.Alertmanager.Templates, s.Opsgenie.Templates, s.Slack.Templates,
+		s.Matrix.Templates, s.Mattermost.Templates, s.Email.Templates,
+	}
+}
+
+// postJSON sends body as JSON and treats any non-2xx status as an error.
+func postJSON(ctx context.Context, client *http.Client, method, url string, header map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 || resp.StatusCode < 200 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if after := retryAfter(resp, body); after > 0 {
+			return &retryError{status: resp.StatusCode, after: after}
+		}
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// retryAfter reads how long a sink asked to wait before retrying, from the
+// Retry-After header or the retry_after field Telegram and Discord put in
+// rate limited responses.
+func retryAfter(resp *http.Response, body []byte) time.Duration {
+	seconds, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+	if err != nil {
+		var limited rateLimited
+		if json.Unmarshal(body, &limited) != nil {
+			return 0
+		}
+		seconds = limited.RetryAfter
+		if limited.Parameters.RetryAfter > 0 {
+			seconds = limited.Parameters.RetryAfter
+		}
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// headline summarises an alert in one line for message titles, such as
+// "CRITICAL miss · mainnet".
+func headline(a Alert) string {
+	title := strings.ToUpper(a.Severity.String()) + " " + a.AlertType.String()
+	if a.Network != "" {
+		title += " · " + a.Network
+	}
+	return title
+}
+
+// truncate cuts text to at most n characters, for sinks that limit a field's
+// length in characters rather than bytes.
+func truncate(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return string([]rune(text)[:n])
+}
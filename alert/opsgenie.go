@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOpsgenieUrl = "https://api.opsgenie.com"
+	maxOpsgenieMessage = 130
+)
+
+func (o *opsgenieNotifier) Name() string {
+	return "opsgenie"
+}
+
+// Send creates an alert aliased by the condition, or closes it by alias when
+// the alert is the matching Clear. Reports are skipped.
+func (o *opsgenieNotifier) Send(ctx context.Context, a Alert) error {
+	if a.AlertType == Report {
+		return nil
+	}
+	base := o.cfg.Url
+	if base == "" {
+		base = defaultOpsgenieUrl
+	}
+	base = strings.TrimSuffix(base, "/") + "/v2/alerts"
+	header := map[string]string{"Authorization": "GenieKey " + o.cfg.Key}
+	message := strings.TrimSpace(a.Message)
+	if a.AlertType == Clear {
+		return postJSON(ctx, o.client, "POST", base+"/"+url.PathEscape(a.Id())+"/close?identifierType=alias", header, opsgenieClose{Source: "penpal", Note: message})
+	}
+	return postJSON(ctx, o.client, "POST", base, header, opsgenieCreate{
+		Message:     truncate(message, maxOpsgenieMessage),
+		Alias:       a.Id(),
+		Description: message + "\n" + a.Time.UTC().Format(time.RFC3339),
+		Priority:    opsgeniePriority(a.Severity),
+		Source:      "penpal",
+		Tags:        []string{"penpal", a.AlertType.String(), a.Severity.String()},
+		Details:     map[string]string{"network": a.Network, "chain_id": a.ChainId, "type": a.AlertType.String()},
+	})
+}
+
+func opsgeniePriority(s Severity) string {
+	switch s {
+	case Critical:
+		return "P1"
+	case Warning:
+		return "P3"
+	default:
+		return "P5"
+	}
+}
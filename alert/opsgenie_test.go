@@ -0,0 +1,37 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestOpsgenieMessageTruncated(t *testing.T) {
+	var created opsgenieCreate
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/alerts" || r.Header.Get("Authorization") != "GenieKey key" {
+			t.Errorf("unexpected request %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&created)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	o := &opsgenieNotifier{client: srv.Client(), cfg: settings.Opsgenie{Key: "key", Url: srv.URL}}
+	a := Missed(5, 20, strings.Repeat("✨", 200))
+	if err := o.Send(context.Background(), a); err != nil {
+		t.Fatalf("send returned error: %v", err)
+	}
+	if !utf8.ValidString(created.Message) || utf8.RuneCountInString(created.Message) != maxOpsgenieMessage {
+		t.Fatalf("expected a valid message of %d characters, got %q", maxOpsgenieMessage, created.Message)
+	}
+	if !strings.Contains(created.Description, strings.Repeat("✨", 200)) {
+		t.Fatalf("expected the full message in the description")
+	}
+}
@@ -0,0 +1,142 @@
+package alert
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+)
+
+// compactAfter is how many journal records may pile up before the journal is
+// rewritten with only the pending ones.
+const compactAfter = 1000
+
+// openOutbox reads the journal at path and keeps the alerts that were queued
+// and never delivered, rewriting the journal with only those.
+func openOutbox(path string) (*outbox, error) {
+	o := &outbox{path: path, pending: make(map[int64]outboxRecord)}
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var record outboxRecord
+			// A crash can leave a partly written last line.
+			if json.Unmarshal(scanner.Bytes(), &record) != nil {
+				continue
+			}
+			if record.Seq > o.seq {
+				o.seq = record.Seq
+			}
+			if record.Alert != nil {
+				o.pending[record.Seq] = record
+			} else {
+				delete(o.pending, record.Seq)
+			}
+		}
+		f.Close()
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if err = o.compact(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// compact replaces the journal with the pending records.
+func (o *outbox) compact() error {
+	tmp, err := os.OpenFile(o.path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, record := range o.undelivered() {
+		line, err := json.Marshal(record)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		_, _ = w.Write(append(line, '\n'))
+	}
+	if err = w.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(o.path+".tmp", o.path); err != nil {
+		return err
+	}
+	if o.file != nil {
+		o.file.Close()
+	}
+	o.file, err = os.OpenFile(o.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	o.written = 0
+	return err
+}
+
+// undelivered returns the pending records in the order they were queued.
+func (o *outbox) undelivered() []outboxRecord {
+	records := make([]outboxRecord, 0, len(o.pending))
+	for _, record := range o.pending {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Seq < records[j].Seq })
+	return records
+}
+
+// add journals an alert queued for a notifier and returns its sequence
+// number. Without an outbox it does nothing.
+func (o *outbox) add(notifier string, a Alert) int64 {
+	if o == nil {
+		return 0
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.seq++
+	record := outboxRecord{Seq: o.seq, Notifier: notifier, Alert: &a}
+	o.pending[record.Seq] = record
+	o.write(record)
+	return record.Seq
+}
+
+// done journals that an alert left the queue, delivered or dead-lettered.
+func (o *outbox) done(seq int64) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.pending, seq)
+	o.write(outboxRecord{Seq: seq})
+	if o.written >= compactAfter {
+		if err := o.compact(); err != nil {
+			log.Println("Failed to compact outbox:", err)
+		}
+	}
+}
+
+// write appends a record to the journal and syncs it to disk, so a queued
+// alert isn't lost to a crash, nor a delivered one sent again.
+func (o *outbox) write(record outboxRecord) {
+	line, err := json.Marshal(record)
+	if err == nil {
+		_, err = o.file.Write(append(line, '\n'))
+	}
+	if err == nil {
+		err = o.file.Sync()
+	}
+	if err != nil {
+		log.Println("Failed to write outbox:", err)
+	}
+	o.written++
+}
@@ -0,0 +1,306 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestOutboxJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	o, err := openOutbox(path)
+	if err != nil {
+		t.Fatalf("openOutbox returned error: %v", err)
+	}
+	first := o.add("slack/1", Missed(5, 20, "val"))
+	o.add("slack/1", NoRpc("chain-1"))
+	o.add("slack/1", Stalled(time.Now(), "chain-1"))
+	o.done(first)
+
+	o, err = openOutbox(path)
+	if err != nil {
+		t.Fatalf("openOutbox returned error: %v", err)
+	}
+	records := o.undelivered()
+	if len(records) != 2 || records[0].Alert.AlertType != RpcError || records[1].Alert.AlertType != Stall {
+		t.Fatalf("expected the two undelivered alerts in order, got %+v", records)
+	}
+	if seq := o.add("slack/1", Missed(5, 20, "val")); seq != 4 {
+		t.Fatalf("expected sequence numbers to continue after reopening, got %d", seq)
+	}
+}
+
+func TestOutboxReplay(t *testing.T) {
+	fastRetries(t)
+	var (
+		mu       sync.Mutex
+		received []discordMessage
+		failures = 7
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Drop the connection, as if the network was down.
+		if failures > 0 {
+			failures--
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		var msg discordMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		received = append(received, msg)
+	}))
+	defer srv.Close()
+
+	var cfg settings.Config
+	cfg.Notifiers.Discord = settings.Discord{Webhook: srv.URL, Embeds: true}
+	cfg.Notifiers.Outbox = filepath.Join(t.TempDir(), "outbox.jsonl")
+	previous, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	// Alerts a previous run queued and never delivered.
+	raised := time.Now().Add(-time.Hour).Truncate(time.Second)
+	stalled := Stalled(raised, "chain-1")
+	stalled.Network, stalled.Time = "mainnet", raised
+	missed := Missed(5, 20, "val")
+	missed.Network, missed.Time = "mainnet", raised.Add(time.Minute)
+	key := previous.keys[previous.For(stalled)[0]]
+	previous.outbox.add(key, stalled)
+	previous.outbox.add(key, missed)
+
+	r, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	r.replay()
+	r.sending.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("expected both alerts delivered once after the outage, got %+v", received)
+	}
+	embed := received[0].Embeds[0]
+	if embed.Timestamp != raised.UTC().Format(time.RFC3339) || !strings.HasPrefix(embed.Description, "⏳ delayed, raised "+raised.UTC().Format(time.RFC1123)+": ⏰") {
+		t.Fatalf("expected the stall first with its original time, got %+v", embed)
+	}
+	if !strings.Contains(received[1].Embeds[0].Description, "missed 5 of 20") {
+		t.Fatalf("expected the missed blocks alert second, got %+v", received[1])
+	}
+	if o, err := openOutbox(cfg.Notifiers.Outbox); err != nil || len(o.undelivered()) != 0 {
+		t.Fatalf("expected nothing left in the outbox, got %v %v", o, err)
+	}
+}
+
+func TestReplayAfterRestart(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg discordMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, msg.Content)
+	}))
+	defer srv.Close()
+
+	var cfg settings.Config
+	cfg.Notifiers.Discord = settings.Discord{Webhook: srv.URL}
+	cfg.Notifiers.Outbox = filepath.Join(t.TempDir(), "outbox.jsonl")
+	previous, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	raised := time.Now().Add(-time.Minute)
+	stalled := Stalled(raised, "chain-1")
+	stalled.Network, stalled.Time = "mainnet", raised
+	missed := Missed(5, 20, "val")
+	missed.Network, missed.Time = "mainnet", raised
+	n := previous.For(missed)[0]
+	key := previous.keys[n]
+	// The missed blocks alert was delivered and saved as such, but the crash
+	// came before its done record. The stall was queued after the last
+	// snapshot and never sent.
+	if _, send := previous.admit(missed); !send {
+		t.Fatalf("expected the missed blocks alert to fire")
+	}
+	previous.delivered(n, missed)
+	snapshot := previous.Snapshot()
+	previous.outbox.add(key, missed)
+	previous.outbox.add(key, stalled)
+
+	r, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	r.Restore(snapshot)
+	r.replay()
+	// The monitor raises the stall again with a new timestamp.
+	again := Stalled(time.Now(), "chain-1")
+	again.Network = "mainnet"
+	if _, send := r.admit(again); send {
+		t.Fatalf("expected the replayed stall not to be sent again")
+	}
+	r.sending.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || !strings.Contains(received[0], "⏰") {
+		t.Fatalf("expected only the stall delivered, once, got %q", received)
+	}
+	if conditions := r.Conditions(); len(conditions) != 2 {
+		t.Fatalf("expected both conditions firing after the replay, got %+v", conditions)
+	}
+}
+
+func TestOutboxCompactsWithPendingAlerts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	o, err := openOutbox(path)
+	if err != nil {
+		t.Fatalf("openOutbox returned error: %v", err)
+	}
+	// An alert stuck on a sink that can't be reached.
+	o.add("slack/1", Stalled(time.Now(), "chain-1"))
+	for i := 0; i < compactAfter; i++ {
+		o.done(o.add("discord/1", Missed(5, 20, "val")))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read outbox: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > compactAfter {
+		t.Fatalf("expected the journal to be compacted, it has %d lines", lines)
+	}
+	if o, err = openOutbox(path); err != nil || len(o.undelivered()) != 1 {
+		t.Fatalf("expected the stuck alert to survive compaction, got %v %v", o, err)
+	}
+}
+
+func TestOutboxGivesUpOnOldAlerts(t *testing.T) {
+	fastRetries(t)
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	var cfg settings.Config
+	cfg.Notifiers.Slack = settings.Slack{Webhook: srv.URL}
+	cfg.Notifiers.Outbox = filepath.Join(t.TempDir(), "outbox.jsonl")
+	cfg.Notifiers.DeadLetter = filepath.Join(t.TempDir(), "dead.jsonl")
+	r, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	a := Stalled(time.Now(), "chain-1")
+	a.Network, a.Time = "mainnet", time.Now().Add(-maxUndeliveredAge)
+	r.deliver(r.For(a), a)
+	r.sending.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != maxRetries {
+		t.Fatalf("expected an alert past its age to stop after %d attempts, got %d", maxRetries, attempts)
+	}
+	if _, err := os.Stat(cfg.Notifiers.DeadLetter); err != nil {
+		t.Fatalf("expected the alert to be dead-lettered: %v", err)
+	}
+	if len(r.outbox.undelivered()) != 0 {
+		t.Fatalf("expected nothing left in the outbox")
+	}
+}
+
+type digestNotifier struct {
+	mu      sync.Mutex
+	sent    []Alert
+	batches [][]Alert
+}
+
+func (d *digestNotifier) Name() string { return "digest" }
+
+func (d *digestNotifier) Send(ctx context.Context, a Alert) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sent = append(d.sent, a)
+	return nil
+}
+
+func (d *digestNotifier) digestEvery() time.Duration { return 200 * time.Millisecond }
+
+func (d *digestNotifier) digests(a Alert) bool { return a.Severity != Critical }
+
+func (d *digestNotifier) SendDigest(ctx context.Context, alerts []Alert) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.batches = append(d.batches, alerts)
+	return nil
+}
+
+func TestDigestKeptInOutbox(t *testing.T) {
+	var cfg settings.Config
+	cfg.Notifiers.Outbox = filepath.Join(t.TempDir(), "outbox.jsonl")
+	r, err := NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	d := &digestNotifier{}
+	r.Register(d)
+	for _, a := range []Alert{NoRpc("chain-1"), Stalled(time.Now(), "chain-1"), Signed(20, 20, "val")} {
+		a.Network = "mainnet"
+		r.deliver(r.For(a), a)
+	}
+	r.sending.Wait()
+	d.mu.Lock()
+	if len(d.sent) != 1 || d.sent[0].AlertType != Stall || len(d.batches) != 0 {
+		t.Fatalf("expected only the critical alert sent at once, got %+v", d.sent)
+	}
+	r.outbox.mu.Lock()
+	held := len(r.outbox.undelivered())
+	r.outbox.mu.Unlock()
+	if held != 2 {
+		t.Fatalf("expected the alerts waiting for the digest to stay in the outbox, got %d", held)
+	}
+	d.mu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		d.mu.Lock()
+		digests := d.batches
+		d.mu.Unlock()
+		if len(digests) > 0 {
+			if len(digests) != 1 || len(digests[0]) != 2 || digests[0][0].AlertType != RpcError {
+				t.Fatalf("expected one digest of the two other alerts, got %+v", digests)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a digest to be sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.outbox.mu.Lock()
+	defer r.outbox.mu.Unlock()
+	if len(r.outbox.undelivered()) != 0 {
+		t.Fatalf("expected the digested alerts to leave the outbox")
+	}
+}
@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPagerDutyUrl = "https://events.pagerduty.com/v2/enqueue"
+	maxPagerDutySummary = 1024
+)
+
+func (p *pagerdutyNotifier) Name() string {
+	return "pagerduty"
+}
+
+// Send triggers an incident for the alert's condition, or resolves it when
+// the alert is the matching Clear. Reports aren't incidents and are skipped.
+func (p *pagerdutyNotifier) Send(ctx context.Context, a Alert) error {
+	if a.AlertType == Report {
+		return nil
+	}
+	url := p.cfg.Url
+	if url == "" {
+		url = defaultPagerDutyUrl
+	}
+	return postJSON(ctx, p.client, "POST", url, nil, pagerdutyEventFor(p.cfg.RoutingKey, a))
+}
+
+func pagerdutyEventFor(routingKey string, a Alert) pagerdutyEvent {
+	event := pagerdutyEvent{RoutingKey: routingKey, EventAction: "trigger", DedupKey: a.Id()}
+	if a.AlertType == Clear {
+		event.EventAction = "resolve"
+		return event
+	}
+	summary := truncate(strings.TrimSpace(a.Message), maxPagerDutySummary)
+	source := a.Network
+	if source == "" {
+		source = "penpal"
+	}
+	event.Payload = &pagerdutyPayload{
+		Summary:   summary,
+		Source:    source,
+		Severity:  a.Severity.String(),
+		Timestamp: a.Time.UTC().Format(time.RFC3339),
+		Class:     a.AlertType.String(),
+	}
+	return event
+}
@@ -0,0 +1,59 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestPagerDutyTriggerAndResolve(t *testing.T) {
+	var events []pagerdutyEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerdutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	pd := &pagerdutyNotifier{client: srv.Client(), cfg: settings.PagerDuty{RoutingKey: "routing", Url: srv.URL}}
+	missed := Missed(5, 20, "val")
+	missed.Network = "mainnet"
+	cleared := Cleared(18, 20, "val")
+	cleared.Network = "mainnet"
+
+	for _, a := range []Alert{missed, cleared} {
+		if err := pd.Send(context.Background(), a); err != nil {
+			t.Fatalf("send returned error: %v", err)
+		}
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].EventAction != "trigger" || events[0].Payload == nil || events[0].Payload.Severity != "critical" {
+		t.Fatalf("unexpected trigger event %+v", events[0])
+	}
+	if events[1].EventAction != "resolve" || events[1].Payload != nil {
+		t.Fatalf("unexpected resolve event %+v", events[1])
+	}
+	if events[0].DedupKey != "penpal/mainnet/missed" || events[1].DedupKey != events[0].DedupKey {
+		t.Fatalf("expected matching dedup keys, got %q and %q", events[0].DedupKey, events[1].DedupKey)
+	}
+}
+
+func TestPagerDutySummaryTruncated(t *testing.T) {
+	a := Missed(5, 20, strings.Repeat("ü", 2000))
+	summary := pagerdutyEventFor("routing", a).Payload.Summary
+	if !utf8.ValidString(summary) || utf8.RuneCountInString(summary) != maxPagerDutySummary || !strings.HasPrefix(summary, "❌") {
+		t.Fatalf("expected a valid summary of %d characters, got %d bytes", maxPagerDutySummary, len(summary))
+	}
+}
@@ -0,0 +1,392 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/internal/metrics"
+)
+
+const (
+	// queueSize bounds the alerts waiting for one notifier, outboxSize when
+	// they are kept on disk. A sink that is down long enough to fill its
+	// queue loses the newest alerts to the dead letter file.
+	queueSize  = 100
+	outboxSize = 10000
+	// delayedAfter is how late an alert is sent before its message says
+	// when it was raised.
+	delayedAfter = 5 * time.Minute
+	// maxUndeliveredAge is how long an alert kept in the outbox is retried
+	// while its sink can't be reached, before it is dead-lettered.
+	maxUndeliveredAge = 24 * time.Hour
+)
+
+// minRetryDelay and maxRetryDelay bound the backoff between attempts to send
+// an alert, unless the sink asks for a longer wait.
+var (
+	minRetryDelay = 1 * time.Second
+	maxRetryDelay = 2 * time.Minute
+)
+
+func (r *retryError) Error() string {
+	return fmt.Sprintf("unexpected status code %d, retry after %s", r.status, r.after)
+}
+
+// deliver queues an alert for each notifier. It doesn't wait for the alert
+// to be sent.
+func (r *Registry) deliver(notifiers []Notifier, a Alert) {
+	for _, n := range notifiers {
+		r.worker(n).enqueue(a)
+	}
+}
+
+// worker returns the worker of a notifier, starting it on first use.
+func (r *Registry) worker(n Notifier) *worker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, exists := r.workers[n]
+	if !exists {
+		w = &worker{notifier: n, key: r.keys[n], registry: r, wake: make(chan struct{}, 1)}
+		r.workers[n] = w
+		go w.run()
+	}
+	return w
+}
+
+// enqueue queues an alert and journals it in the outbox.
+func (w *worker) enqueue(a Alert) {
+	limit := queueSize
+	if w.registry.outbox != nil {
+		limit = outboxSize
+	}
+	w.mu.Lock()
+	full := len(w.queue) >= limit
+	w.mu.Unlock()
+	if full {
+		w.fail(a, errors.New("queue full"))
+		return
+	}
+	w.push(queued{seq: w.registry.outbox.add(w.key, a), alert: a})
+}
+
+// refresh queues a firing alert to be sent again, unless a refresh of its
+// condition is still waiting, so a sink that is down doesn't pile them up.
+func (w *worker) refresh(a Alert) {
+	w.mu.Lock()
+	if w.refreshing[a.Id()] {
+		w.mu.Unlock()
+		return
+	}
+	if w.refreshing == nil {
+		w.refreshing = make(map[string]bool)
+	}
+	w.refreshing[a.Id()] = true
+	w.mu.Unlock()
+	w.push(queued{alert: a, refresh: true})
+}
+
+func (w *worker) push(q queued) {
+	w.registry.sending.Add(1)
+	w.mu.Lock()
+	w.queue = append(w.queue, q)
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *worker) run() {
+	var flush <-chan time.Time
+	d := digesterOf(w.notifier)
+	if d != nil {
+		flush = time.NewTicker(d.digestEvery()).C
+	}
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			select {
+			case <-w.wake:
+			case <-flush:
+				w.flush(d)
+			}
+			continue
+		}
+		q := w.queue[0]
+		w.mu.Unlock()
+
+		w.send(q)
+
+		w.mu.Lock()
+		w.queue = w.queue[1:]
+		w.mu.Unlock()
+		w.registry.sending.Done()
+	}
+}
+
+// digesterOf returns a notifier as a digester if it batches alerts.
+func digesterOf(n Notifier) digester {
+	d, ok := unwrap(n).(digester)
+	if !ok || d.digestEvery() <= 0 {
+		return nil
+	}
+	return d
+}
+
+// send delivers an alert, or holds it for the digest if the sink batches it.
+// The rest of the queue waits, so a sink gets its alerts in order.
+func (w *worker) send(q queued) {
+	name := w.notifier.Name()
+	if q.refresh {
+		w.mu.Lock()
+		delete(w.refreshing, q.alert.Id())
+		w.mu.Unlock()
+		// A failed refresh is made up for by the next one.
+		if err := w.notifier.Send(context.Background(), q.alert); err != nil {
+			log.Println("Error refreshing alert", q.alert.Id(), "on", name+":", err)
+		}
+		return
+	}
+	if d := digesterOf(w.notifier); d != nil && d.digests(q.alert) {
+		if t, ok := w.notifier.(*templated); ok {
+			q.alert.Message = render(t.templates, q.alert)
+		}
+		// It stays in the outbox until the digest is sent.
+		w.digest = append(w.digest, q)
+		return
+	}
+	err := w.retry("message "+q.alert.Message, q.alert.Time, func() error {
+		return w.notifier.Send(context.Background(), w.registry.delayed(q.alert))
+	})
+	if err == nil {
+		log.Println("Sent alert to", name, q.alert.Message)
+		metrics.AlertsSent.Inc(name, q.alert.AlertType.String())
+		w.registry.delivered(w.notifier, q.alert)
+	} else {
+		w.fail(q.alert, err)
+	}
+	w.registry.outbox.done(q.seq)
+}
+
+// flush sends the digest of the alerts held since the last one. If it can't
+// be sent, each alert is dead-lettered.
+func (w *worker) flush(d digester) {
+	held := w.digest
+	w.digest = nil
+	if len(held) == 0 {
+		return
+	}
+	alerts := make([]Alert, len(held))
+	for i, q := range held {
+		alerts[i] = q.alert
+	}
+	name := w.notifier.Name()
+	err := w.retry("digest of "+strconv.Itoa(len(alerts))+" alerts", alerts[0].Time, func() error {
+		return d.SendDigest(context.Background(), alerts)
+	})
+	if err == nil {
+		log.Println("Sent", name, "digest of", len(alerts), "alerts")
+		metrics.AlertsSent.Inc(name, "digest")
+	}
+	for _, q := range held {
+		if err != nil {
+			w.fail(q.alert, err)
+		} else {
+			w.registry.delivered(w.notifier, q.alert)
+		}
+		w.registry.outbox.done(q.seq)
+	}
+}
+
+// retry calls send until it succeeds, giving up after maxRetries attempts.
+// With an outbox, a sink that can't be reached is retried until it can, as
+// long as what is sent was raised less than maxUndeliveredAge ago.
+func (w *worker) retry(what string, raised time.Time, send func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			delay := retryDelay(attempt, err)
+			log.Printf("Error sending %s to %s: %v. Retrying in %s...", what, w.notifier.Name(), err, delay)
+			time.Sleep(delay)
+		}
+		if err = send(); err == nil {
+			return nil
+		}
+		waiting := w.registry.outbox != nil && unreachable(err) && time.Since(raised) < maxUndeliveredAge
+		if attempt+1 >= maxRetries && !waiting {
+			return err
+		}
+	}
+}
+
+// unreachable reports whether an error means the sink couldn't be reached at
+// all, rather than that it refused the alert.
+func unreachable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// delayed notes when an alert sent late was raised, since chat sinks don't
+// show the time an alert was raised.
+func (r *Registry) delayed(a Alert) Alert {
+	if a.Time.IsZero() || time.Since(a.Time) < delayedAfter {
+		return a
+	}
+	a.Note = "⏳ delayed, raised " + a.Time.UTC().Format(time.RFC1123) + ": " + a.Note
+	a.Message = render(r.templates, a)
+	return a
+}
+
+// delivered notes that a notifier got the alert of a firing condition, so a
+// replay after a restart doesn't send it again.
+func (r *Registry) delivered(n Notifier, a Alert) {
+	if a.AlertType == Clear || events[a.Kind] {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, exists := r.conditions[a.Id()]; exists {
+		c.delivered = appendUnique(c.delivered, n)
+	}
+}
+
+// replay queues the alerts a previous run left undelivered in the outbox, in
+// the order they were raised. Alerts the restored conditions show were
+// delivered to a notifier are skipped, as are repeats of the same type for
+// one condition and notifier. The rest are admitted again, so raising a
+// condition replayed here doesn't send it a second time.
+func (r *Registry) replay() {
+	if r.outbox == nil {
+		return
+	}
+	r.mu.Lock()
+	notifiers := r.byKey()
+	r.mu.Unlock()
+
+	r.outbox.mu.Lock()
+	records := r.outbox.undelivered()
+	r.outbox.mu.Unlock()
+	last := make(map[string]AlertType)
+	replayed := 0
+	for _, record := range records {
+		a := *record.Alert
+		n, exists := notifiers[record.Notifier]
+		key := record.Notifier + " " + a.Id()
+		previous, seen := last[key]
+		switch {
+		case !exists:
+			kind := strings.SplitN(record.Notifier, "/", 2)[0]
+			r.fail(kind, a, errors.New("notifier no longer configured"))
+			r.outbox.done(record.Seq)
+		case seen && previous == a.AlertType:
+			r.outbox.done(record.Seq)
+		case !r.readmit(n, a):
+			r.outbox.done(record.Seq)
+		default:
+			last[key] = a.AlertType
+			replayed++
+			r.worker(n).push(queued{seq: record.Seq, alert: a})
+		}
+	}
+	if len(records) > 0 {
+		log.Println("Replaying", replayed, "of", len(records), "undelivered alerts from the outbox")
+	}
+}
+
+// readmit brings the condition of a replayed alert up to date and reports
+// whether the alert still needs sending. The snapshot is saved every few
+// seconds, so a condition can be missing because it was raised just before
+// the restart, or still firing because its clear was.
+func (r *Registry) readmit(n Notifier, a Alert) bool {
+	if events[a.Kind] {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := a.Id()
+	c, exists := r.conditions[id]
+	switch {
+	case a.AlertType == Clear:
+		delete(r.conditions, id)
+	case !exists:
+		r.conditions[id] = &condition{alert: a, state: Firing, severity: a.Severity, since: a.Time, firedAt: a.Time, lastSent: a.Time, notifiers: []Notifier{n}}
+	case c.state == Firing && hasNotifier(c.delivered, n):
+		return false
+	default:
+		c.state = Firing
+		c.notifiers = appendUnique(c.notifiers, n)
+	}
+	return true
+}
+
+func (w *worker) fail(a Alert, err error) {
+	w.registry.fail(w.notifier.Name(), a, err)
+}
+
+// fail gives up on sending an alert to a notifier and records it in the dead
+// letter file.
+func (r *Registry) fail(name string, a Alert, err error) {
+	metrics.AlertsFailed.Inc(name, a.AlertType.String())
+	log.Printf("Error sending message %s to %s: %v. Skipping further notifications.", a.Message, name, err)
+	r.deadLetter(deadLetter{
+		Time:     time.Now(),
+		Notifier: name,
+		Error:    err.Error(),
+		Id:       a.Id(),
+		Network:  a.Network,
+		Type:     a.AlertType.String(),
+		Severity: a.Severity.String(),
+		Message:  a.Message,
+		Raised:   a.Time,
+	})
+}
+
+// deadLetter appends an undelivered alert to the dead letter file as a line
+// of JSON, when one is configured.
+func (r *Registry) deadLetter(letter deadLetter) {
+	path := r.cfg.Notifiers.DeadLetter
+	if path == "" {
+		return
+	}
+	line, err := json.Marshal(letter)
+	if err != nil {
+		log.Println("Failed to encode dead letter:", err)
+		return
+	}
+	r.deadLetters.Lock()
+	defer r.deadLetters.Unlock()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Println("Failed to open dead letter file:", err)
+		return
+	}
+	defer f.Close()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		log.Println("Failed to write dead letter file:", err)
+	}
+}
+
+// retryDelay is how long to wait before an attempt: as long as the sink
+// asked, or an exponential backoff with jitter so sinks failing together
+// don't retry in lockstep.
+func retryDelay(attempt int, err error) time.Duration {
+	var retry *retryError
+	if errors.As(err, &retry) {
+		return retry.after
+	}
+	delay := maxRetryDelay
+	if attempt < 32 && minRetryDelay<<(attempt-1) < maxRetryDelay {
+		delay = minRetryDelay << (attempt - 1)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
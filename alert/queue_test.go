@@ -0,0 +1,153 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+// fastRetries shortens the backoff for a test.
+func fastRetries(t *testing.T) {
+	minDelay, maxDelay := minRetryDelay, maxRetryDelay
+	minRetryDelay, maxRetryDelay = time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() { minRetryDelay, maxRetryDelay = minDelay, maxDelay })
+}
+
+func TestQueueRetryAfter(t *testing.T) {
+	fastRetries(t)
+	var (
+		mu       sync.Mutex
+		received []string
+		attempts = make(map[string]int)
+		last     = make(map[string]time.Time)
+		waited   = make(map[string]time.Duration)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text    string `json:"text"`
+			Content string `json:"content"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[r.URL.Path]++
+		if wait := time.Since(last[r.URL.Path]); !last[r.URL.Path].IsZero() && wait > waited[r.URL.Path] {
+			waited[r.URL.Path] = wait
+		}
+		last[r.URL.Path] = time.Now()
+		if attempts[r.URL.Path] == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			if strings.HasPrefix(r.URL.Path, "/bot") {
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"ok": false, "error_code": 429, "description": "Too Many Requests: retry after 1", "parameters": {"retry_after": 1}}`))
+				return
+			}
+			w.Header().Set("Retry-After", "0.3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 0.3, "global": false}`))
+			return
+		}
+		received = append(received, r.URL.Path+" "+body.Text+body.Content)
+	}))
+	defer srv.Close()
+
+	var cfg settings.Config
+	cfg.Notifiers.Telegram = settings.Telegram{Key: "key", Chat: "1", ApiUrl: srv.URL}
+	cfg.Notifiers.Discord = settings.Discord{Webhook: srv.URL + "/webhook"}
+	r, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		a := Missed(i, 20, "val")
+		r.deliver(r.For(a), a)
+	}
+	r.sending.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if waited["/botkey/sendMessage"] < time.Second || waited["/webhook"] < 300*time.Millisecond {
+		t.Fatalf("expected the retries to wait as long as asked, waited %v", waited)
+	}
+	var telegram, discord []string
+	for _, line := range received {
+		if strings.HasPrefix(line, "/bot") {
+			telegram = append(telegram, line)
+		} else {
+			discord = append(discord, line)
+		}
+	}
+	if len(telegram) != 3 || len(discord) != 3 {
+		t.Fatalf("expected every alert delivered to both sinks, got %q", received)
+	}
+	for i, line := range telegram {
+		if !strings.Contains(line, "missed "+strconv.Itoa(i+1)+" of 20") {
+			t.Fatalf("expected alerts in order, got %q", telegram)
+		}
+	}
+}
+
+func TestQueueDeadLetter(t *testing.T) {
+	fastRetries(t)
+	var (
+		mu       sync.Mutex
+		attempts int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	var cfg settings.Config
+	cfg.Notifiers.Slack = settings.Slack{Webhook: srv.URL}
+	cfg.Notifiers.DeadLetter = filepath.Join(t.TempDir(), "dead.jsonl")
+	r, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	a := Stalled(time.Now(), "chain-1")
+	a.Network, a.Time = "mainnet", time.Now()
+	r.deliver(r.For(a), a)
+	r.sending.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != maxRetries {
+		t.Fatalf("expected %d attempts, got %d", maxRetries, attempts)
+	}
+	data, err := os.ReadFile(cfg.Notifiers.DeadLetter)
+	if err != nil {
+		t.Fatalf("expected a dead letter file: %v", err)
+	}
+	var letter deadLetter
+	if err = json.Unmarshal(data, &letter); err != nil {
+		t.Fatalf("invalid dead letter %s: %v", data, err)
+	}
+	if letter.Notifier != "slack" || letter.Id != "penpal/mainnet/stall" || letter.Error != "unexpected status code 502" || !letter.Raised.Equal(a.Time) {
+		t.Fatalf("unexpected dead letter %+v", letter)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 1; attempt < 40; attempt++ {
+		delay := retryDelay(attempt, nil)
+		if delay < minRetryDelay/2 || delay > maxRetryDelay {
+			t.Fatalf("attempt %d: delay %s out of bounds", attempt, delay)
+		}
+	}
+	if delay := retryDelay(1, &retryError{status: 429, after: time.Minute}); delay != time.Minute {
+		t.Fatalf("expected the requested delay, got %s", delay)
+	}
+}
@@ -0,0 +1,78 @@
+package alert
+
+import (
+	"log"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+// destinations returns the sinks an alert is routed to: the global notifiers
+// unless the alert's network overrides them, plus the network's own
+// notifiers and the named notifiers it uses.
+func destinations(cfg settings.Config, a Alert) []settings.Sinks {
+	var own *settings.NetworkNotifiers
+	for i := range cfg.Networks {
+		if a.Network != "" && cfg.Networks[i].Name == a.Network {
+			own = &cfg.Networks[i].Notifiers
+			break
+		}
+	}
+
+	var sinks []settings.Sinks
+	if own == nil || !own.Override {
+		sinks = append(sinks, routed(cfg.Notifiers.Sinks, cfg.Notifiers.Routes, a)...)
+	}
+	if own != nil {
+		sinks = append(sinks, routed(own.Sinks, own.Routes, a)...)
+		for _, name := range own.Use {
+			sinks = append(sinks, cfg.Notifiers.Named[name])
+		}
+	}
+	return sinks
+}
+
+// routed returns every route that matches the alert, or the default sinks
+// when none do.
+func routed(defaults settings.Sinks, routes []settings.Route, a Alert) []settings.Sinks {
+	var sinks []settings.Sinks
+	for _, route := range routes {
+		if routeMatches(route, a) {
+			sinks = append(sinks, route.Sinks)
+		}
+	}
+	if len(sinks) == 0 && !defaults.Empty() {
+		sinks = append(sinks, defaults)
+	}
+	return sinks
+}
+
+func routeMatches(route settings.Route, a Alert) bool {
+	return matchesAny(route.Severities, a.Severity.String()) && matchesAny(route.Types, a.AlertType.String())
+}
+
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRoutes logs route types that no alert will ever match.
+func checkRoutes(cfg settings.Config) {
+	routes := append([]settings.Route{}, cfg.Notifiers.Routes...)
+	for _, network := range cfg.Networks {
+		routes = append(routes, network.Notifiers.Routes...)
+	}
+	for _, route := range routes {
+		for _, t := range route.Types {
+			if !matchesAny(alertTypeNames[:], t) {
+				log.Println("warning! route alert type", t, "is unknown and will never match")
+			}
+		}
+	}
+}
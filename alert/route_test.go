@@ -0,0 +1,143 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestDestinations(t *testing.T) {
+	var cfg settings.Config
+	cfg.Notifiers.Discord.Webhook = "https://discord.test/ops"
+	pager := settings.Route{Severities: []string{"critical"}}
+	pager.Telegram = settings.Telegram{Key: "key", Chat: "pager"}
+	stalls := settings.Route{Types: []string{"stall"}}
+	stalls.Telegram = settings.Telegram{Key: "key", Chat: "pager"}
+	cfg.Notifiers.Routes = []settings.Route{pager, stalls}
+
+	if sinks := destinations(cfg, Cleared(20, 20, "val")); len(sinks) != 1 || sinks[0].Discord.Webhook == "" {
+		t.Fatalf("expected info alert on the default sinks, got %+v", sinks)
+	}
+	sinks := destinations(cfg, Stalled(time.Now(), "chain-1"))
+	if len(sinks) != 2 {
+		t.Fatalf("expected critical stall to match both routes, got %d", len(sinks))
+	}
+	registry, err := NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	if n := registry.For(Stalled(time.Now(), "chain-1")); len(n) != 1 || n[0].Name() != "telegram" {
+		t.Fatalf("expected one telegram notifier for a shared chat, got %+v", n)
+	}
+}
+
+func TestNetworkDestinations(t *testing.T) {
+	var cfg settings.Config
+	cfg.Notifiers.Discord.Webhook = "https://discord.test/global"
+	cfg.Notifiers.Named = map[string]settings.Sinks{"client-a": {Discord: settings.Discord{Webhook: "https://discord.test/client-a"}}}
+	testnet := settings.Network{Name: "testnet"}
+	testnet.Notifiers.Override = true
+	testnet.Notifiers.Discord.Webhook = "https://discord.test/testnet"
+	mainnet := settings.Network{Name: "mainnet"}
+	mainnet.Notifiers.Use = []string{"client-a"}
+	cfg.Networks = []settings.Network{testnet, mainnet}
+
+	a := Missed(5, 20, "testnet")
+	a.Network = "testnet"
+	if sinks := destinations(cfg, a); len(sinks) != 1 || sinks[0].Discord.Webhook != "https://discord.test/testnet" {
+		t.Fatalf("expected only the testnet webhook, got %+v", sinks)
+	}
+	a.Network = "mainnet"
+	if sinks := destinations(cfg, a); len(sinks) != 2 || sinks[1].Discord.Webhook != "https://discord.test/client-a" {
+		t.Fatalf("expected the global and client-a webhooks, got %+v", sinks)
+	}
+	if sinks := destinations(cfg, PeerRecovered("peer")); len(sinks) != 1 || sinks[0].Discord.Webhook != "https://discord.test/global" {
+		t.Fatalf("expected peer alerts on the global webhook, got %+v", sinks)
+	}
+}
+
+type recordingNotifier struct{ sent []Alert }
+
+func (r *recordingNotifier) Name() string { return "recording" }
+
+func (r *recordingNotifier) Send(ctx context.Context, a Alert) error {
+	r.sent = append(r.sent, a)
+	return nil
+}
+
+func TestRegistryCustomNotifier(t *testing.T) {
+	var cfg settings.Config
+	cfg.Notifiers.Discord.Webhook = "https://discord.test/ops"
+	registry, err := NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	custom := &recordingNotifier{}
+	registry.Register(custom)
+
+	notifiers := registry.For(Missed(5, 20, "val"))
+	if len(notifiers) != 2 || notifiers[0].Name() != "discord" || notifiers[1] != custom {
+		t.Fatalf("expected discord and the custom notifier, got %+v", notifiers)
+	}
+	if again := registry.For(Missed(5, 20, "val")); again[0] != notifiers[0] {
+		t.Fatalf("expected the discord notifier to be reused")
+	}
+}
+
+func TestClearFollowsCondition(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		telegram int
+		events   []pagerdutyEvent
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/bot") {
+			telegram++
+			return
+		}
+		var event pagerdutyEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	var cfg settings.Config
+	cfg.Notifiers.Telegram = settings.Telegram{Key: "key", Chat: "ops", ApiUrl: srv.URL}
+	pager := settings.Route{Severities: []string{"critical"}}
+	pager.PagerDuty = settings.PagerDuty{RoutingKey: "routing", Url: srv.URL + "/enqueue"}
+	cfg.Notifiers.Routes = []settings.Route{pager}
+	registry, err := NewRegistry(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	if n := registry.For(Cleared(20, 20, "val")); len(n) != 1 || n[0].Name() != "telegram" {
+		t.Fatalf("expected a clear on its own to be routed to telegram, got %+v", n)
+	}
+
+	alerts := make(chan Alert)
+	go Watch(alerts, cfg, registry)
+	alerts <- Missed(5, 20, "val")
+	alerts <- Cleared(20, 20, "val")
+	// Watch has handled the clear once it takes the next alert.
+	alerts <- Nil("done")
+	registry.sending.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0].EventAction != "trigger" || events[1].EventAction != "resolve" {
+		t.Fatalf("expected the incident triggered and resolved on pagerduty, got %+v", events)
+	}
+	if telegram != 0 {
+		t.Fatalf("expected nothing on telegram, got %d messages", telegram)
+	}
+}
@@ -0,0 +1,242 @@
+package alert
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+var recurrences = map[string]time.Duration{
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// NewSilence checks a silence from the config or the health API and resolves
+// its times against now.
+func NewSilence(s settings.Silence, now time.Time) (Silence, error) {
+	silence := Silence{Networks: s.Networks, Types: s.Types, Start: now, Recurring: s.Recurring, Reason: s.Reason, Downgrade: s.Downgrade}
+	var err error
+	if s.Start != "" {
+		if silence.Start, err = time.Parse(time.RFC3339, s.Start); err != nil {
+			return Silence{}, errors.New("silence start \"" + s.Start + "\" invalid")
+		}
+	}
+	switch {
+	case s.End != "":
+		if silence.End, err = time.Parse(time.RFC3339, s.End); err != nil {
+			return Silence{}, errors.New("silence end \"" + s.End + "\" invalid")
+		}
+	case s.Duration != "":
+		d, err := time.ParseDuration(s.Duration)
+		if err != nil {
+			return Silence{}, errors.New("silence duration \"" + s.Duration + "\" invalid")
+		}
+		silence.End = silence.Start.Add(d)
+	default:
+		return Silence{}, errors.New("silence needs an end or a duration")
+	}
+	if !silence.End.After(silence.Start) {
+		return Silence{}, errors.New("silence ends before it starts")
+	}
+	if s.Recurring != "" {
+		every, known := recurrences[s.Recurring]
+		if !known {
+			return Silence{}, errors.New("silence recurrence \"" + s.Recurring + "\" invalid")
+		}
+		if silence.End.Sub(silence.Start) >= every {
+			return Silence{}, errors.New("recurring silence is longer than its period")
+		}
+	}
+	for _, t := range s.Types {
+		if !matchesAny(alertTypeNames[:], t) {
+			return Silence{}, errors.New("silence alert type \"" + t + "\" invalid")
+		}
+	}
+	if silence.Reason == "" {
+		silence.Reason = "maintenance"
+	}
+	return silence, nil
+}
+
+// activeAt reports whether t falls in the silence's window, or in one of its
+// repeats for a recurring silence.
+func (s Silence) activeAt(t time.Time) bool {
+	if t.Before(s.Start) {
+		return false
+	}
+	every := recurrences[s.Recurring]
+	if every == 0 {
+		return t.Before(s.End)
+	}
+	return t.Sub(s.Start)%every < s.End.Sub(s.Start)
+}
+
+func (s Silence) matches(a Alert) bool {
+	if len(s.Networks) > 0 && !matchesAny(s.Networks, a.Network) {
+		return false
+	}
+	return matchesAny(s.Types, a.AlertType.String())
+}
+
+// Silence adds a silence and returns it with its id.
+func (r *Registry) Silence(s Silence) Silence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.addSilence(s)
+}
+
+func (r *Registry) addSilence(s Silence) Silence {
+	if s.Id == "" {
+		r.silenceId++
+		s.Id = strconv.Itoa(r.silenceId)
+	}
+	for _, existing := range r.silences {
+		if existing.Id == s.Id && !existing.ended {
+			existing.Silence = s
+			return s
+		}
+	}
+	r.silences = append(r.silences, &silence{Silence: s})
+	return s
+}
+
+// Unsilence ends a silence early. What it suppressed is still reported.
+func (r *Registry) Unsilence(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.silences {
+		if s.Id == id && !s.ended {
+			s.ended = true
+			return true
+		}
+	}
+	return false
+}
+
+// Silences returns the silences that haven't ended.
+func (r *Registry) Silences() []Silence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	silences := make([]Silence, 0, len(r.silences))
+	for _, s := range r.silences {
+		if !s.ended {
+			listed := s.Silence
+			listed.Suppressed = len(s.suppressed)
+			silences = append(silences, listed)
+		}
+	}
+	return silences
+}
+
+// CheckNetworks returns an error for a network the silence names that isn't
+// in the config, like the config's own silences are checked.
+func (r *Registry) CheckNetworks(s Silence) error {
+	for _, name := range s.Networks {
+		if !r.cfg.HasNetwork(name) {
+			return errors.New("silence network " + name + " not found")
+		}
+	}
+	return nil
+}
+
+// Mute silences a network until the given time, every alert when network is
+// empty. Muting a network again replaces its mute.
+func (r *Registry) Mute(network string, until time.Time) {
+	s := Silence{Id: muteId(network), Start: time.Now(), End: until, Reason: "muted"}
+	if network != "" {
+		s.Networks = []string{network}
+		s.Reason += " " + network
+	}
+	r.Silence(s)
+}
+
+func (r *Registry) Unmute(network string) {
+	r.Unsilence(muteId(network))
+}
+
+// Muted returns when each muted network unmutes, "" for all alerts.
+func (r *Registry) Muted() map[string]time.Time {
+	muted := make(map[string]time.Time)
+	now := time.Now()
+	for _, s := range r.Silences() {
+		if strings.HasPrefix(s.Id, "mute-") && s.activeAt(now) {
+			muted[strings.Join(s.Networks, "")] = s.End
+		}
+	}
+	return muted
+}
+
+func muteId(network string) string {
+	if network == "" {
+		return "mute-all"
+	}
+	return "mute-" + network
+}
+
+func (r *Registry) silencing(a Alert, now time.Time) *silence {
+	for _, s := range r.silences {
+		if !s.ended && s.activeAt(now) && s.matches(a) {
+			return s
+		}
+	}
+	return nil
+}
+
+// endSilences returns a report for every silence whose window ended with
+// alerts suppressed, and the alerts of pending conditions no silence holds
+// back anymore. Silences that won't recur are removed.
+func (r *Registry) endSilences(now time.Time) (reports []Alert, held []Alert) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	remaining := r.silences[:0]
+	for _, s := range r.silences {
+		over := s.ended || (s.Recurring == "" && !now.Before(s.End))
+		if (over || !s.activeAt(now)) && len(s.suppressed) > 0 {
+			reports = append(reports, SilenceEnded(s.Silence, s.suppressed))
+			s.suppressed = nil
+		}
+		if !over {
+			remaining = append(remaining, s)
+		}
+	}
+	r.silences = remaining
+
+	for _, c := range r.conditions {
+		if c.state == Pending && r.silencing(c.alert, now) == nil {
+			held = append(held, c.alert)
+		}
+	}
+	sortByTime(held)
+	return reports, held
+}
+
+// summarise counts alerts by type, most frequent first.
+func summarise(alerts []Alert) string {
+	counts := make(map[string]int)
+	for _, a := range alerts {
+		counts[a.AlertType.String()]++
+	}
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	parts := make([]string, len(types))
+	for i, t := range types {
+		parts[i] = strconv.Itoa(counts[t]) + " " + t
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortByTime(alerts []Alert) {
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Time.Before(alerts[j].Time) })
+}
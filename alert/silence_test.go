@@ -0,0 +1,101 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestMuteAndAck(t *testing.T) {
+	r, err := NewRegistry(settings.Config{}, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	stalled := Stalled(time.Now(), "chain-1")
+	stalled.Network = "mainnet"
+	cleared := SlashingWindowCleared("mainnet", 0, 500)
+	cleared.Network = "mainnet"
+
+	if _, send := r.admit(stalled); !send || len(r.Active()) != 1 {
+		t.Fatalf("expected the stall to be sent and active")
+	}
+	if acked := r.Ack("mainnet"); len(acked) != 1 {
+		t.Fatalf("expected the stall to be acknowledged")
+	}
+	if _, send := r.admit(stalled); send {
+		t.Fatalf("expected the acknowledged stall to stop repeating")
+	}
+
+	r.Mute("", time.Now().Add(time.Hour))
+	missed := Missed(5, 20, "mainnet")
+	missed.Network = "mainnet"
+	if _, send := r.admit(missed); send {
+		t.Fatalf("expected alerts to be muted")
+	}
+	if _, send := r.admit(cleared); send {
+		t.Fatalf("expected a clear for an alert that never fired to be muted")
+	}
+	stallCleared := stalled
+	stallCleared.AlertType = Clear
+	if _, send := r.admit(stallCleared); !send || len(r.Active()) != 0 {
+		t.Fatalf("expected the clear of a sent alert to go through the mute")
+	}
+	r.Unmute("")
+	if _, send := r.admit(missed); !send {
+		t.Fatalf("expected alerts after unmuting")
+	}
+}
+
+func TestSilences(t *testing.T) {
+	now := time.Now()
+	var cfg settings.Config
+	cfg.Silences = []settings.Silence{{Networks: []string{"mainnet"}, Types: []string{"rpc_error", "stall"}, Duration: "1h", Reason: "upgrade"}}
+	r, err := NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	rpcDown := NoRpc("chain-1")
+	rpcDown.Network = "mainnet"
+	stalled := Stalled(now, "chain-1")
+	stalled.Network = "mainnet"
+	missed := Missed(5, 20, "mainnet")
+	missed.Network = "mainnet"
+	for _, a := range []Alert{rpcDown, stalled, stalled} {
+		if _, send := r.admit(a); send {
+			t.Fatalf("expected %s to be silenced", a.AlertType)
+		}
+	}
+	if _, send := r.admit(missed); !send {
+		t.Fatalf("expected alerts of other types to be sent")
+	}
+	stallCleared := SlashingWindowCleared("mainnet", 0, 500)
+	stallCleared.Network, stallCleared.Kind = "mainnet", KindStall
+	r.admit(stallCleared)
+
+	reports, held := r.endSilences(now.Add(30 * time.Minute))
+	if len(reports) != 0 || len(held) != 0 {
+		t.Fatalf("expected nothing while the silence is active")
+	}
+	reports, held = r.endSilences(now.Add(2 * time.Hour))
+	if len(reports) != 1 || reports[0].Message != `🔕 silence "upgrade" ended, 2 alerts suppressed: 1 rpc_error, 1 stall` || reports[0].Network != "mainnet" {
+		t.Fatalf("unexpected reports %+v", reports)
+	}
+	if len(held) != 1 || held[0].AlertType != RpcError {
+		t.Fatalf("expected only the uncleared rpc alert to be raised again, got %+v", held)
+	}
+	if len(r.Silences()) != 0 {
+		t.Fatalf("expected the silence to be removed")
+	}
+
+	if _, err := NewSilence(settings.Silence{Start: "2024-01-06T22:00:00Z", Duration: "25h", Recurring: "daily"}, now); err == nil {
+		t.Fatalf("expected a recurring silence longer than a day to be rejected")
+	}
+	weekly, err := NewSilence(settings.Silence{Start: "2024-01-06T22:00:00Z", Duration: "2h", Recurring: "weekly"}, now)
+	if err != nil {
+		t.Fatalf("NewSilence returned error: %v", err)
+	}
+	if !weekly.activeAt(time.Date(2024, 3, 2, 23, 0, 0, 0, time.UTC)) || weekly.activeAt(time.Date(2024, 3, 3, 1, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the weekly silence to repeat on saturday nights only")
+	}
+}
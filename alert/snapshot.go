@@ -0,0 +1,93 @@
+package alert
+
+import (
+	"sort"
+	"strings"
+)
+
+// Snapshot returns the state to restore after a restart.
+func (r *Registry) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := Snapshot{Conditions: []savedCondition{}, Silences: []savedSilence{}, SilenceId: r.silenceId}
+	for id, c := range r.conditions {
+		s.Conditions = append(s.Conditions, savedCondition{
+			Id:        id,
+			Alert:     c.alert,
+			State:     c.state,
+			Severity:  c.severity,
+			Since:     c.since,
+			FiredAt:   c.firedAt,
+			LastSent:  c.lastSent,
+			Acked:     c.acked,
+			Repeats:   c.repeats,
+			Escalated: c.escalated,
+			Notifiers: r.keysOf(c.notifiers),
+			Delivered: r.keysOf(c.delivered),
+		})
+	}
+	sort.Slice(s.Conditions, func(i, j int) bool { return s.Conditions[i].Id < s.Conditions[j].Id })
+	for _, silence := range r.silences {
+		if !silence.ended {
+			s.Silences = append(s.Silences, savedSilence{Silence: silence.Silence, Suppressed: silence.suppressed})
+		}
+	}
+	return s
+}
+
+// Restore puts back the state of a previous run. Silences from the config
+// are loaded again from it, so only what they suppressed is restored, and
+// conditions only keep the notifiers that are still configured. Notifiers
+// must be registered before restoring.
+func (r *Registry) Restore(s Snapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s.SilenceId > r.silenceId {
+		r.silenceId = s.SilenceId
+	}
+	byKey := r.byKey()
+	for _, c := range s.Conditions {
+		r.conditions[c.Id] = &condition{
+			alert:     c.Alert,
+			state:     c.State,
+			severity:  c.Severity,
+			since:     c.Since,
+			firedAt:   c.FiredAt,
+			lastSent:  c.LastSent,
+			acked:     c.Acked,
+			repeats:   c.Repeats,
+			escalated: c.Escalated,
+			notifiers: notifiersOf(byKey, c.Notifiers),
+			delivered: notifiersOf(byKey, c.Delivered),
+		}
+	}
+	for _, saved := range s.Silences {
+		if !strings.HasPrefix(saved.Silence.Id, "config-") {
+			r.addSilence(saved.Silence)
+		}
+		for _, existing := range r.silences {
+			if existing.Id == saved.Silence.Id && !existing.ended {
+				existing.suppressed = saved.Suppressed
+			}
+		}
+	}
+}
+
+func (r *Registry) keysOf(notifiers []Notifier) []string {
+	keys := make([]string, 0, len(notifiers))
+	for _, n := range notifiers {
+		keys = append(keys, r.keys[n])
+	}
+	return keys
+}
+
+// notifiersOf returns the notifiers with those keys that are still configured.
+func notifiersOf(byKey map[string]Notifier, keys []string) []Notifier {
+	var notifiers []Notifier
+	for _, key := range keys {
+		if n, exists := byKey[key]; exists {
+			notifiers = append(notifiers, n)
+		}
+	}
+	return notifiers
+}
@@ -0,0 +1,54 @@
+package alert
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	r, err := NewRegistry(settings.Config{}, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	stalled := Stalled(time.Now(), "chain-1")
+	stalled.Network = "mainnet"
+	missed := Missed(5, 20, "testnet")
+	missed.Network = "testnet"
+	r.admit(stalled)
+	r.Ack("mainnet")
+	r.Mute("testnet", time.Now().Add(time.Hour))
+	r.admit(missed)
+
+	data, err := json.Marshal(r.Snapshot())
+	if err != nil {
+		t.Fatalf("failed to encode snapshot: %v", err)
+	}
+	var snapshot Snapshot
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("failed to decode snapshot: %v", err)
+	}
+	restarted, err := NewRegistry(settings.Config{}, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	restarted.Restore(snapshot)
+
+	if _, send := restarted.admit(stalled); send {
+		t.Fatalf("expected the stall to still be firing after a restart")
+	}
+	conditions := restarted.Conditions()
+	if len(conditions) != 2 || !conditions[0].Acked || conditions[1].State != Pending {
+		t.Fatalf("unexpected conditions %+v", conditions)
+	}
+	if silences := restarted.Silences(); len(silences) != 1 || silences[0].Id != "mute-testnet" || silences[0].Suppressed != 1 {
+		t.Fatalf("expected the mute to be restored, got %+v", silences)
+	}
+	cleared := StallCleared("chain-1")
+	cleared.Network = "mainnet"
+	if _, send := restarted.admit(cleared); !send {
+		t.Fatalf("expected the stall to clear after a restart")
+	}
+}
@@ -0,0 +1,94 @@
+package alert
+
+import (
+	"context"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+const defaultTelegramApi = "https://api.telegram.org"
+
+// markdownV2Special are the characters MarkdownV2 requires escaping outside
+// of code and links.
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+func (t *telegramNotifier) Name() string {
+	return "telegram"
+}
+
+func (t *telegramNotifier) Send(ctx context.Context, a Alert) error {
+	msg := telegramMessage{Chat: t.cfg.Chat, Text: a.Message}
+	switch t.cfg.ParseMode {
+	case "HTML":
+		msg.Text, msg.ParseMode, msg.DisableWebPagePreview = telegramHTML(a), "HTML", true
+	case "MarkdownV2":
+		msg.Text, msg.ParseMode, msg.DisableWebPagePreview = telegramMarkdown(a), "MarkdownV2", true
+	}
+	return postJSON(ctx, t.client, "POST", TelegramApi(t.cfg)+"/bot"+t.cfg.Key+"/sendMessage", nil, msg)
+}
+
+// TelegramApi returns the Bot API base url for a Telegram sink.
+func TelegramApi(cfg settings.Telegram) string {
+	if cfg.ApiUrl == "" {
+		return defaultTelegramApi
+	}
+	return strings.TrimSuffix(cfg.ApiUrl, "/")
+}
+
+func telegramHTML(a Alert) string {
+	text := "<b>" + html.EscapeString(headline(a)) + "</b>\n" + html.EscapeString(strings.TrimSpace(a.Message))
+	var details []string
+	if a.ChainId != "" {
+		details = append(details, "chain <code>"+html.EscapeString(a.ChainId)+"</code>")
+	}
+	if a.Data.Window > 0 {
+		details = append(details, "missed "+strconv.Itoa(a.Data.Missed)+"/"+strconv.Itoa(a.Data.Window))
+	}
+	if a.Data.Height > 0 {
+		height := strconv.FormatInt(a.Data.Height, 10)
+		if a.Data.Link != "" {
+			height = `<a href="` + html.EscapeString(a.Data.Link) + `">` + height + "</a>"
+		}
+		details = append(details, "height "+height)
+	}
+	if len(details) > 0 {
+		text += "\n" + strings.Join(details, " · ")
+	}
+	return text
+}
+
+func telegramMarkdown(a Alert) string {
+	text := "*" + escapeMarkdownV2(headline(a)) + "*\n" + escapeMarkdownV2(strings.TrimSpace(a.Message))
+	var details []string
+	if a.ChainId != "" {
+		details = append(details, "chain `"+strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(a.ChainId)+"`")
+	}
+	if a.Data.Window > 0 {
+		details = append(details, "missed "+strconv.Itoa(a.Data.Missed)+"/"+strconv.Itoa(a.Data.Window))
+	}
+	if a.Data.Height > 0 {
+		height := strconv.FormatInt(a.Data.Height, 10)
+		if a.Data.Link != "" {
+			height = "[" + height + "](" + strings.NewReplacer("\\", "\\\\", ")", "\\)").Replace(a.Data.Link) + ")"
+		}
+		details = append(details, "height "+height)
+	}
+	if len(details) > 0 {
+		text += "\n" + strings.Join(details, " · ")
+	}
+	return text
+}
+
+func escapeMarkdownV2(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(markdownV2Special, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
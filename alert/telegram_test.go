@@ -0,0 +1,23 @@
+package alert
+
+import (
+	"testing"
+)
+
+func TestTelegramFormatting(t *testing.T) {
+	a := Missed(5, 20, "val_1")
+	a.Network, a.ChainId = "main-net", "chain-1"
+	a.Data.Height, a.Data.Missed, a.Data.Window = 1234, 5, 20
+	a.Data.Link = "https://explorer.test/blocks/1234?a=1&b=(2)"
+
+	want := "<b>CRITICAL miss · main-net</b>\n❌ val_1 missed 5 of 20 recent blocks\n" +
+		`chain <code>chain-1</code> · missed 5/20 · height <a href="https://explorer.test/blocks/1234?a=1&amp;b=(2)">1234</a>`
+	if got := telegramHTML(a); got != want {
+		t.Fatalf("unexpected html\n%s\nwant\n%s", got, want)
+	}
+	want = "*CRITICAL miss · main\\-net*\n❌ val\\_1 missed 5 of 20 recent blocks\n" +
+		"chain `chain-1` · missed 5/20 · height [1234](https://explorer.test/blocks/1234?a=1&b=(2\\))"
+	if got := telegramMarkdown(a); got != want {
+		t.Fatalf("unexpected markdown\n%s\nwant\n%s", got, want)
+	}
+}
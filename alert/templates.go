@@ -0,0 +1,112 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultTemplates are the built-in message of every alert, by template name.
+var defaultTemplates = map[string]string{
+	"missed":                  ` ❌ {{.Name}} missed {{.Missed}} of {{.Window}} recent blocks `,
+	"cleared":                 ` ♿️ {{.Name}} is recovering, {{.Signed}} of {{.Window}} recent blocks signed `,
+	"signed":                  ` ✅ {{.Name}} signed {{.Signed}} of {{.Window}} recent blocks `,
+	"nil_voted":               ` 🗳️ {{.Name}} voted nil on {{.NilVotes}} of {{.Window}} recent blocks `,
+	"nil_votes_cleared":       ` ♿️ {{.Name}} nil votes back to {{.NilVotes}} of {{.Window}} recent blocks `,
+	"no_rpc":                  `📡 no rpcs available for {{.ChainId}}`,
+	"rpc_recovered":           ` ♿️ rpcs for {{.ChainId}} are reachable again `,
+	"invalid_height":          `❓ Invalid height for {{.ChainId}}`,
+	"stalled":                 `⏰ warning - last block {{.ChainId}} produced at {{rfc1123 .Time}}`,
+	"stall_cleared":           ` ✅ {{.ChainId}} is producing blocks again `,
+	"signer_down":             `📡 signer metrics {{.Name}} are down`,
+	"signer_recovered":        ` ♿️ signer metrics {{.Name}} recovered `,
+	"signer_error":            ` ❌ signer {{.Name}} reported {{.Errors}} errors `,
+	"signer_stalled":          `⏰ warning - last signer checkpoint {{.Name}} at {{rfc1123 .Time}}`,
+	"peer_unreachable":        `💀 penpal peer {{.Name}} unreachable for {{.Intervals}} checks`,
+	"peer_unhealthy":          `🤒 penpal peer {{.Name}} unhealthy for {{.Intervals}} checks`,
+	"peer_recovered":          ` ♿️ penpal peer {{.Name}} is reachable again `,
+	"jailed":                  `⛓️ {{.Name}} is jailed{{if future .Time}}, eligible to unjail at {{rfc1123 .Time}}{{end}}`,
+	"unjailed":                ` ✅ {{.Name}} is no longer jailed `,
+	"tombstoned":              `🪦 {{.Name}} is tombstoned and can never be unjailed`,
+	"slashing_window":         `⚠️ {{.Name}} used {{.Percent}}% of its jail budget, {{.MissedCounter}} of {{.MaxMissed}} missed blocks allowed, {{.Remaining}} remaining{{if gt .Duration 0}}, jailed in about {{duration .Duration}} if it keeps missing{{end}}`,
+	"slashing_window_cleared": ` ♿️ {{.Name}} missed blocks counter back to {{.MissedCounter}} of {{.MaxMissed}} allowed `,
+	"silence_ended":           `🔕 silence "{{.Name}}" ended, {{.Count}} alerts suppressed: {{.Summary}}`,
+}
+
+var templateFuncs = template.FuncMap{
+	"rfc1123": func(t time.Time) string { return t.Format(time.RFC1123) },
+	"rfc3339": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"future":  func(t time.Time) bool { return t.After(time.Now()) },
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Second).String()
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+var builtinTemplates = template.Must(parseTemplates())
+
+// parseTemplates parses the default templates with each set of overrides
+// applied in turn, later ones taking precedence.
+func parseTemplates(overrides ...map[string]string) (*template.Template, error) {
+	texts := make(map[string]string, len(defaultTemplates))
+	for name, text := range defaultTemplates {
+		texts[name] = text
+	}
+	for _, o := range overrides {
+		for name, text := range o {
+			if _, known := defaultTemplates[name]; !known {
+				return nil, errors.New("unknown alert template " + name)
+			}
+			texts[name] = text
+		}
+	}
+	root := template.New("").Funcs(templateFuncs)
+	for name, text := range texts {
+		if _, err := root.New(name).Parse(text); err != nil {
+			return nil, fmt.Errorf("alert template %s: %w", name, err)
+		}
+	}
+	// Execute each template once so unknown fields fail at startup rather
+	// than when the alert fires.
+	for name := range texts {
+		if err := root.ExecuteTemplate(io.Discard, name, Data{}); err != nil {
+			return nil, fmt.Errorf("alert template %s: %w", name, err)
+		}
+	}
+	return root, nil
+}
+
+// rendered sets an alert's message from the built-in templates.
+func rendered(a Alert) Alert {
+	a.Message = render(builtinTemplates, a)
+	return a
+}
+
+// render executes the alert's template with its data, keeping the current
+// message if the alert has no template or it fails to execute.
+func render(t *template.Template, a Alert) string {
+	if a.Template == "" {
+		return a.Message
+	}
+	data := a.Data
+	if a.Network != "" {
+		data.Network = a.Network
+	}
+	if a.ChainId != "" {
+		data.ChainId = a.ChainId
+	}
+	var sb strings.Builder
+	if err := t.ExecuteTemplate(&sb, a.Template, data); err != nil {
+		log.Println("Error rendering alert template", a.Template+":", err)
+		return a.Message
+	}
+	if a.Note != "" {
+		return a.Note + strings.TrimSpace(sb.String())
+	}
+	return sb.String()
+}
@@ -0,0 +1,45 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestTemplates(t *testing.T) {
+	until := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := Jailed("val", until).Message; got != "⛓️ val is jailed" {
+		t.Fatalf("unexpected jailed message %q", got)
+	}
+	if got := SlashingWindow("val", 80, 400, 500, 90*time.Second).Message; got != "⚠️ val used 80% of its jail budget, 400 of 500 missed blocks allowed, 100 remaining, jailed in about 1m30s if it keeps missing" {
+		t.Fatalf("unexpected slashing message %q", got)
+	}
+
+	var cfg settings.Config
+	cfg.Notifiers.Templates = map[string]string{"missed": "VALIDATOR MISSING BLOCKS {{.Network}} {{.Missed}}/{{.Window}}"}
+	cfg.Notifiers.Slack = settings.Slack{Webhook: "https://slack.test/hook", Templates: map[string]string{"missed": "{{upper .Name}} missed {{.Missed}} at {{.Height}}"}}
+	registry, err := NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	a := Missed(5, 20, "val")
+	a.Network, a.Data.Height = "mainnet", 1234
+	if got := render(registry.templates, a); got != "VALIDATOR MISSING BLOCKS mainnet 5/20" {
+		t.Fatalf("unexpected global override %q", got)
+	}
+	slack, ok := registry.For(a)[0].(*templated)
+	if !ok {
+		t.Fatalf("expected the slack sink to render its own templates")
+	}
+	if got := render(slack.templates, a); got != "VAL missed 5 at 1234" {
+		t.Fatalf("unexpected sink override %q", got)
+	}
+
+	for _, overrides := range []map[string]string{{"mised": "typo"}, {"missed": "{{.Nope}}"}, {"missed": "{{if}}"}} {
+		cfg.Notifiers.Templates = overrides
+		if _, err := NewRegistry(cfg, nil); err == nil {
+			t.Fatalf("expected %v to be rejected", overrides)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/cordtus/penpal/settings"
+)
+
+const (
+	None AlertType = iota
+	Clear
+	RpcError
+	Error
+	Miss
+	Jail
+	Stall
+	PeerDown
+	NilVote
+	// Report summarises what a silence suppressed. It goes to chat sinks
+	// and is ignored by incident sinks.
+	Report
+	Unknown
+)
+
+const (
+	Info Severity = iota
+	Warning
+	Critical
+)
+
+// Kinds identify the condition an alert is about, so a Clear alert can be
+// matched with the alert it resolves.
+const (
+	KindMissed       = "missed"
+	KindNilVote      = "nil_vote"
+	KindRpc          = "rpc"
+	KindHeight       = "height"
+	KindStall        = "stall"
+	KindSigner       = "signer"
+	KindSignerErrors = "signer_errors"
+	KindPeer         = "peer"
+	KindJail         = "jail"
+	KindTombstone    = "tombstone"
+	KindSlashing     = "slashing"
+	KindSilence      = "silence"
+)
+
+// States are the lifecycle of a condition. A condition is pending while a
+// silence holds it back, firing once its alert was sent and resolved when it
+// clears.
+const (
+	Pending State = iota
+	Firing
+	Resolved
+)
+
+var severityNames = [...]string{"info", "warning", "critical"}
+
+var alertTypeNames = [...]string{"none", "clear", "rpc_error", "error", "miss", "jail", "stall", "peer_down", "nil_vote", "report", "unknown"}
+
+var stateNames = [...]string{"pending", "firing", "resolved"}
+
+type (
+	AlertType int
+
+	Severity int
+
+	State int
+
+	Alert struct {
+		AlertType AlertType
+		Severity  Severity
+		// Network is the name of the network the alert came from, empty for
+		// alerts about penpal itself.
+		Network string
+		ChainId string
+		Kind    string
+		// Subject narrows the condition within a kind, such as the peer or
+		// rpc url an alert is about.
+		Subject string
+		Message string
+		// Template names the message template rendered from Data, empty for
+		// alerts with a fixed Message.
+		Template string
+		Data     Data
+		// Note is put before the rendered message, such as a reminder or
+		// escalation marker.
+		Note string
+		// Time is when the alert was raised, set by Watch when left empty.
+		Time time.Time
+		// Resolves is the alert last sent for the condition a Clear resolves,
+		// set when the clear is sent.
+		Resolves *Alert `json:",omitempty"`
+	}
+
+	// Data is what message templates are executed with. Fields that don't
+	// apply to an alert are left zero.
+	Data struct {
+		// Name is the network, signer or peer the alert is about.
+		Name    string
+		Network string
+		ChainId string
+		Url     string
+		// Height is the newest block counted, Link points at it on the
+		// network's block explorer.
+		Height   int64
+		Link     string
+		Missed   int
+		Signed   int
+		NilVotes int
+		Window   int
+		Errors   int64
+		// Intervals is how many peer checks failed in a row.
+		Intervals int
+		// Percent, MissedCounter, MaxMissed and Remaining describe the
+		// slashing window, Duration the estimated time until jailing.
+		Percent       int
+		MissedCounter int64
+		MaxMissed     int64
+		Remaining     int64
+		Duration      time.Duration
+		// Time is the last block, last signer checkpoint or end of jail.
+		Time time.Time
+		// Count and Summary describe the alerts a silence suppressed.
+		Count   int
+		Summary string
+	}
+
+	// Notifier delivers alerts to one sink. Name identifies the kind of sink
+	// in logs and metrics.
+	Notifier interface {
+		Name() string
+		Send(ctx context.Context, a Alert) error
+	}
+
+	// Registry resolves the notifiers an alert is routed to. Notifiers are
+	// built from settings the first time a sink is used, and embedders can
+	// Register their own sinks, which receive every alert.
+	Registry struct {
+		mu     sync.Mutex
+		cfg    settings.Config
+		client *http.Client
+		// templates are the built-in templates with the global overrides.
+		templates *template.Template
+		built     map[string]Notifier
+		custom    []Notifier
+		// keys identify notifiers in the outbox across restarts.
+		keys      map[Notifier]string
+		silences  []*silence
+		silenceId int
+		// conditions are the pending and firing conditions by alert id.
+		conditions map[string]*condition
+		// workers deliver the alerts queued for each notifier, and sending
+		// counts the alerts queued and not yet delivered or dead-lettered.
+		workers map[Notifier]*worker
+		sending sync.WaitGroup
+		// deadLetters serialises writes to the dead letter file.
+		deadLetters sync.Mutex
+		outbox      *outbox
+	}
+
+	// Snapshot is the state of a Registry that outlives a restart: its
+	// conditions and the silences added at runtime.
+	Snapshot struct {
+		Conditions []savedCondition `json:"conditions"`
+		Silences   []savedSilence   `json:"silences"`
+		SilenceId  int              `json:"silence_id"`
+	}
+
+	savedCondition struct {
+		Id        string    `json:"id"`
+		Alert     Alert     `json:"alert"`
+		State     State     `json:"state"`
+		Severity  Severity  `json:"severity"`
+		Since     time.Time `json:"since"`
+		FiredAt   time.Time `json:"fired_at"`
+		LastSent  time.Time `json:"last_sent"`
+		Acked     bool      `json:"acked"`
+		Repeats   int       `json:"repeats"`
+		Escalated bool      `json:"escalated"`
+		Notifiers []string  `json:"notifiers,omitempty"`
+		Delivered []string  `json:"delivered,omitempty"`
+	}
+
+	savedSilence struct {
+		Silence    Silence `json:"silence"`
+		Suppressed []Alert `json:"suppressed"`
+	}
+
+	// worker delivers the alerts queued for one notifier in order, retrying
+	// each with backoff before giving up on it.
+	worker struct {
+		notifier Notifier
+		key      string
+		registry *Registry
+		mu       sync.Mutex
+		queue    []queued
+		// wake is signalled when the queue was empty and an alert is added.
+		wake chan struct{}
+		// digest holds the alerts batched for a digester's next digest.
+		digest []queued
+		// refreshing are the conditions with a refresh in the queue.
+		refreshing map[string]bool
+	}
+
+	// digester is a notifier that batches some alerts into a digest sent
+	// every digestEvery, such as email with digest_mins set.
+	digester interface {
+		Notifier
+		digestEvery() time.Duration
+		digests(a Alert) bool
+		SendDigest(ctx context.Context, alerts []Alert) error
+	}
+
+	// queued is an alert waiting for a worker and its outbox sequence number.
+	// A refresh resends a firing alert to a sink that would otherwise expire
+	// it, and isn't journaled or retried.
+	queued struct {
+		seq     int64
+		alert   Alert
+		refresh bool
+	}
+
+	// outbox journals the alerts queued for notifiers, so the ones not yet
+	// delivered survive a restart.
+	outbox struct {
+		mu      sync.Mutex
+		path    string
+		file    *os.File
+		seq     int64
+		pending map[int64]outboxRecord
+		// written counts records since the journal was last compacted.
+		written int
+	}
+
+	// outboxRecord is a line of the outbox journal. An alert is written with
+	// its notifier when queued, and its sequence number alone once it was
+	// delivered or dead-lettered.
+	outboxRecord struct {
+		Seq      int64  `json:"seq"`
+		Notifier string `json:"notifier,omitempty"`
+		Alert    *Alert `json:"alert,omitempty"`
+	}
+
+	// retryError is a sink refusing an alert for now, such as a rate limited
+	// request, with how long it asked to wait before retrying.
+	retryError struct {
+		status int
+		after  time.Duration
+	}
+
+	// rateLimited is the body of a rate limited Telegram or Discord request.
+	rateLimited struct {
+		RetryAfter float64 `json:"retry_after"`
+		Parameters struct {
+			RetryAfter float64 `json:"retry_after"`
+		} `json:"parameters"`
+	}
+
+	// deadLetter is a line of the dead letter file.
+	deadLetter struct {
+		Time     time.Time `json:"time"`
+		Notifier string    `json:"notifier"`
+		Error    string    `json:"error"`
+		Id       string    `json:"id"`
+		Network  string    `json:"network,omitempty"`
+		Type     string    `json:"type"`
+		Severity string    `json:"severity"`
+		Message  string    `json:"message"`
+		Raised   time.Time `json:"raised"`
+	}
+
+	// Condition is a problem tracked from when it is first raised until it
+	// clears. Its Id is the same for every alert about the condition.
+	Condition struct {
+		Id       string    `json:"id"`
+		State    State     `json:"state"`
+		Network  string    `json:"network,omitempty"`
+		Type     string    `json:"type"`
+		Severity string    `json:"severity"`
+		Message  string    `json:"message"`
+		Since    time.Time `json:"since"`
+		Acked    bool      `json:"acked"`
+	}
+
+	// condition is the state of a Condition. alert is the last alert sent
+	// for it, or the one held back while pending, and severity what that
+	// alert was raised with before a silence downgraded it.
+	condition struct {
+		alert    Alert
+		state    State
+		severity Severity
+		since    time.Time
+		firedAt  time.Time
+		lastSent time.Time
+		acked    bool
+		// repeats and escalated are what its escalation has done so far.
+		repeats   int
+		escalated bool
+		// notifiers are every notifier an alert for the condition was sent
+		// to, which its clear goes to, and delivered the ones that got it.
+		notifiers []Notifier
+		delivered []Notifier
+	}
+
+	// delivery is an alert and the notifiers it goes to.
+	delivery struct {
+		alert     Alert
+		notifiers []Notifier
+	}
+
+	// Silence drops alerts of Types from Networks between Start and End,
+	// repeating daily or weekly when Recurring is set. Empty lists match
+	// every alert. With Downgrade set, matching alerts are sent as info.
+	Silence struct {
+		Id         string    `json:"id"`
+		Networks   []string  `json:"networks,omitempty"`
+		Types      []string  `json:"types,omitempty"`
+		Start      time.Time `json:"start"`
+		End        time.Time `json:"end"`
+		Recurring  string    `json:"recurring,omitempty"`
+		Reason     string    `json:"reason"`
+		Downgrade  bool      `json:"downgrade,omitempty"`
+		Suppressed int       `json:"suppressed"`
+	}
+
+	// silence is a Silence with the alerts it dropped in its current window.
+	silence struct {
+		Silence
+		suppressed []Alert
+		// ended is set when a silence is removed early, so its report still
+		// goes out.
+		ended bool
+	}
+
+	// templated renders alerts with a sink's own template overrides before
+	// handing them to the sink.
+	templated struct {
+		Notifier
+		templates *template.Template
+	}
+
+	telegramNotifier struct {
+		client *http.Client
+		cfg    settings.Telegram
+	}
+
+	discordNotifier struct {
+		client *http.Client
+		cfg    settings.Discord
+	}
+
+	pagerdutyNotifier struct {
+		client *http.Client
+		cfg    settings.PagerDuty
+	}
+
+	alertmanagerNotifier struct {
+		client *http.Client
+		cfg    settings.Alertmanager
+	}
+
+	opsgenieNotifier struct {
+		client *http.Client
+		cfg    settings.Opsgenie
+	}
+
+	slackNotifier struct {
+		client *http.Client
+		cfg    settings.Slack
+	}
+
+	matrixNotifier struct {
+		client *http.Client
+		cfg    settings.Matrix
+	}
+
+	mattermostNotifier struct {
+		client *http.Client
+		cfg    settings.Mattermost
+	}
+
+	emailNotifier struct {
+		cfg settings.Email
+	}
+
+	telegramMessage struct {
+		Chat                  string `json:"chat_id,omitempty"`
+		Text                  string `json:"text,omitempty"`
+		ParseMode             string `json:"parse_mode,omitempty"`
+		DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty"`
+	}
+
+	discordMessage struct {
+		Username string         `json:"username"`
+		Content  string         `json:"content,omitempty"`
+		Embeds   []discordEmbed `json:"embeds,omitempty"`
+	}
+
+	discordEmbed struct {
+		Title       string         `json:"title"`
+		Description string         `json:"description,omitempty"`
+		Url         string         `json:"url,omitempty"`
+		Color       int            `json:"color"`
+		Fields      []discordField `json:"fields,omitempty"`
+		Timestamp   string         `json:"timestamp,omitempty"`
+	}
+
+	discordField struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Inline bool   `json:"inline"`
+	}
+
+	pagerdutyEvent struct {
+		RoutingKey  string            `json:"routing_key"`
+		EventAction string            `json:"event_action"`
+		DedupKey    string            `json:"dedup_key"`
+		Payload     *pagerdutyPayload `json:"payload,omitempty"`
+	}
+
+	pagerdutyPayload struct {
+		Summary   string `json:"summary"`
+		Source    string `json:"source"`
+		Severity  string `json:"severity"`
+		Timestamp string `json:"timestamp"`
+		Class     string `json:"class,omitempty"`
+	}
+
+	alertmanagerAlert struct {
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+		StartsAt    time.Time         `json:"startsAt"`
+		EndsAt      *time.Time        `json:"endsAt,omitempty"`
+	}
+
+	opsgenieCreate struct {
+		Message     string            `json:"message"`
+		Alias       string            `json:"alias"`
+		Description string            `json:"description"`
+		Priority    string            `json:"priority"`
+		Source      string            `json:"source"`
+		Tags        []string          `json:"tags"`
+		Details     map[string]string `json:"details"`
+	}
+
+	slackMessage struct {
+		Text   string       `json:"text"`
+		Blocks []slackBlock `json:"blocks"`
+	}
+
+	slackBlock struct {
+		Type     string      `json:"type"`
+		Text     *slackText  `json:"text,omitempty"`
+		Elements []slackText `json:"elements,omitempty"`
+	}
+
+	slackText struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+
+	matrixMessage struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	}
+
+	mattermostMessage struct {
+		Username string `json:"username"`
+		Channel  string `json:"channel,omitempty"`
+		Text     string `json:"text"`
+	}
+
+	emailMessage struct {
+		Subject string
+		Body    string
+	}
+
+	opsgenieClose struct {
+		Source string `json:"source"`
+		Note   string `json:"note"`
+	}
+)
+
+func (t AlertType) String() string {
+	if t < 0 || int(t) >= len(alertTypeNames) {
+		return alertTypeNames[Unknown]
+	}
+	return alertTypeNames[t]
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return severityNames[Critical]
+	}
+	return severityNames[s]
+}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return stateNames[Resolved]
+	}
+	return stateNames[s]
+}
+
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *State) UnmarshalText(text []byte) error {
+	for i, name := range stateNames {
+		if name == string(text) {
+			*s = State(i)
+			return nil
+		}
+	}
+	return errors.New("unknown alert state " + string(text))
+}
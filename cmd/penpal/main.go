@@ -7,11 +7,17 @@ import (
 	"os"
 	"strings"
 
-	"github.com/cordtus/penpal/internal/scan"
-	"github.com/cordtus/penpal/internal/settings"
+	"github.com/cordtus/penpal/scan"
+	"github.com/cordtus/penpal/settings"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "silence" {
+		if err := silence(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	var (
 		file string
 		init bool
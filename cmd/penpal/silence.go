@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/settings"
+)
+
+// silence creates, lists or removes silences on a running penpal through
+// its health server.
+func silence(args []string) error {
+	var (
+		file, url, networks, types, remove string
+		list                               bool
+		s                                  settings.Silence
+	)
+	fs := flag.NewFlagSet("silence", flag.ExitOnError)
+	fs.StringVar(&file, "config", "./config.json", "path to the config file")
+	fs.StringVar(&file, "c", "./config.json", "path to the config file [shorthand]")
+	fs.StringVar(&url, "url", "", "health server url, defaults to the local one from the config")
+	fs.StringVar(&networks, "network", "", "comma separated networks to silence, all when empty")
+	fs.StringVar(&types, "type", "", "comma separated alert types to silence, all when empty")
+	fs.StringVar(&s.Start, "start", "", "RFC3339 start time, defaults to now")
+	fs.StringVar(&s.End, "end", "", "RFC3339 end time")
+	fs.StringVar(&s.Duration, "duration", "", "how long the silence lasts, e.g. 2h")
+	fs.StringVar(&s.Recurring, "recurring", "", "daily or weekly")
+	fs.StringVar(&s.Reason, "reason", "", "why alerts are silenced")
+	fs.BoolVar(&s.Downgrade, "downgrade", false, "send matching alerts as info instead of dropping them")
+	fs.BoolVar(&list, "list", false, "list silences")
+	fs.StringVar(&remove, "delete", "", "id of a silence to end early")
+	_ = fs.Parse(args)
+
+	cfg, err := settings.Load(file)
+	if err != nil {
+		return err
+	}
+	if url == "" {
+		if cfg.Health.Port == "" {
+			return errors.New("health port not set in config - set it or pass -url")
+		}
+		url = "http://" + localAddr(cfg.Health.Port)
+	}
+	url = strings.TrimSuffix(url, "/") + "/silences"
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	switch {
+	case list:
+		var silences []alert.Silence
+		if err = call(client, "GET", url, cfg.Health.Token, nil, &silences); err != nil {
+			return err
+		}
+		if len(silences) == 0 {
+			fmt.Println("no silences")
+		}
+		for _, s := range silences {
+			fmt.Println(describe(s))
+		}
+	case remove != "":
+		if err = call(client, "DELETE", url+"/"+remove, cfg.Health.Token, nil, nil); err != nil {
+			return err
+		}
+		fmt.Println("removed silence", remove)
+	default:
+		s.Networks, s.Types = split(networks), split(types)
+		var created alert.Silence
+		if err = call(client, "POST", url, cfg.Health.Token, s, &created); err != nil {
+			return err
+		}
+		fmt.Println("created silence", describe(created))
+	}
+	return nil
+}
+
+func call(client *http.Client, method, url, token string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 || resp.StatusCode < 200 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %d %s", method, url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func describe(s alert.Silence) string {
+	scope := "all networks"
+	if len(s.Networks) > 0 {
+		scope = strings.Join(s.Networks, ",")
+	}
+	if len(s.Types) > 0 {
+		scope += " (" + strings.Join(s.Types, ",") + ")"
+	}
+	line := fmt.Sprintf("%s: %s, %s from %s to %s", s.Id, s.Reason, scope, s.Start.Format(time.RFC1123), s.End.Format(time.RFC1123))
+	if s.Recurring != "" {
+		line += " " + s.Recurring
+	}
+	return line + fmt.Sprintf(", %d suppressed", s.Suppressed)
+}
+
+// localAddr turns the health port setting into an address on this machine.
+func localAddr(port string) string {
+	if !strings.Contains(port, ":") {
+		return "127.0.0.1:" + port
+	}
+	host, p, err := net.SplitHostPort(port)
+	if err != nil {
+		return port
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, p)
+}
+
+func split(list string) []string {
+	var values []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -1,3 +1,10 @@
 module github.com/cordtus/penpal
 
 go 1.20
+
+require (
+	github.com/gorilla/websocket v1.5.3
+	go.etcd.io/bbolt v1.3.9
+)
+
+require golang.org/x/sys v0.10.0 // indirect
@@ -0,0 +1,331 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/internal/health"
+	"github.com/cordtus/penpal/settings"
+)
+
+const (
+	pollTimeout = 30 // seconds getUpdates waits for a command
+	retryDelay  = 5 * time.Second
+	// maxCommandAge drops commands queued while penpal was down, so a stale
+	// /mute isn't applied hours later.
+	maxCommandAge = 5 * time.Minute
+	maxReplyLen   = 4000
+)
+
+const usage = `commands:
+/status - window and active rpc of each network
+/uptime [network] - recent signing stats
+/mute <network|all> <duration> - silence alerts, e.g. /mute mainnet 30m
+/unmute <network|all>
+/ack [network] - stop active alerts repeating until they clear`
+
+// Watch starts a bot for every Telegram sink with commands enabled. Sinks
+// sharing a bot key share one bot, which answers all of their chats.
+func Watch(ctx context.Context, cfg settings.Config, status *health.Registry, notifiers *alert.Registry) {
+	bots := make(map[string]*Bot)
+	var order []string
+	for _, sinks := range cfg.AllSinks() {
+		t := sinks.Telegram
+		if t.Key == "" || !t.Commands {
+			continue
+		}
+		api := alert.TelegramApi(t) + "/bot" + t.Key
+		b, exists := bots[api]
+		if !exists {
+			b = newBot(api, status, notifiers)
+			bots[api] = b
+			order = append(order, api)
+		}
+		b.chats[t.Chat] = true
+	}
+	for _, api := range order {
+		go bots[api].run(ctx)
+	}
+}
+
+func newBot(api string, status *health.Registry, notifiers *alert.Registry) *Bot {
+	return &Bot{
+		api:       api,
+		chats:     make(map[string]bool),
+		client:    &http.Client{Timeout: (pollTimeout + 10) * time.Second},
+		status:    status,
+		notifiers: notifiers,
+	}
+}
+
+func (b *Bot) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		updates, err := b.getUpdates(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Println("Telegram getUpdates failed:", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+		for _, u := range updates {
+			b.offset = u.UpdateId + 1
+			b.handle(ctx, u)
+		}
+	}
+}
+
+func (b *Bot) getUpdates(ctx context.Context) ([]update, error) {
+	url := b.api + "/getUpdates?timeout=" + strconv.Itoa(pollTimeout) + "&offset=" + strconv.FormatInt(b.offset, 10)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var updates updatesResponse
+	if err = json.NewDecoder(resp.Body).Decode(&updates); err != nil {
+		return nil, fmt.Errorf("unexpected response with status code %d: %w", resp.StatusCode, err)
+	}
+	if !updates.Ok {
+		return nil, errors.New(updates.Description)
+	}
+	return updates.Result, nil
+}
+
+func (b *Bot) handle(ctx context.Context, u update) {
+	m := u.Message
+	if m == nil || !b.chats[strconv.FormatInt(m.Chat.Id, 10)] {
+		return
+	}
+	if time.Since(time.Unix(m.Date, 0)) > maxCommandAge {
+		log.Println("Ignoring stale telegram command", m.Text)
+		return
+	}
+	text := b.answer(m.Text)
+	if text == "" {
+		return
+	}
+	text = truncate(text, maxReplyLen)
+	if err := b.send(ctx, reply{Chat: m.Chat.Id, Text: text}); err != nil {
+		log.Println("Failed to answer telegram command", m.Text+":", err)
+	}
+}
+
+// truncate cuts text to at most n bytes plus an ellipsis, on a rune boundary.
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n] + "…"
+}
+
+func (b *Bot) send(ctx context.Context, r reply) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", b.api+"/sendMessage", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 || resp.StatusCode < 200 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// answer returns the reply to a command, empty for messages that aren't
+// commands.
+func (b *Bot) answer(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+	// Commands in groups arrive as /status@penpalbot.
+	command := strings.SplitN(fields[0], "@", 2)[0]
+	args := fields[1:]
+	switch command {
+	case "/status":
+		return b.statusText()
+	case "/uptime":
+		return b.uptime(args)
+	case "/mute":
+		return b.mute(args)
+	case "/unmute":
+		return b.unmute(args)
+	case "/ack":
+		return b.ack(args)
+	default:
+		return usage
+	}
+}
+
+func (b *Bot) statusText() string {
+	muted := b.notifiers.Muted()
+	var sb strings.Builder
+	for _, s := range b.status.Snapshot() {
+		sb.WriteString(s.Name + " (" + s.ChainId + ")\n")
+		sb.WriteString("height " + strconv.FormatInt(s.Height, 10) + " · signed " + strconv.Itoa(s.Signed) + " of " + strconv.Itoa(s.Window) +
+			" · missed " + strconv.Itoa(s.Missed) + " · nil " + strconv.Itoa(s.NilVotes) + "\n")
+		if s.ActiveRpc == "" {
+			sb.WriteString("no rpc available\n")
+		} else {
+			sb.WriteString("rpc " + s.ActiveRpc + "\n")
+		}
+		var flags []string
+		if s.Alerted {
+			flags = append(flags, "alerting")
+		}
+		if s.Jailed {
+			flags = append(flags, "jailed")
+		}
+		if s.Tombstoned {
+			flags = append(flags, "tombstoned")
+		}
+		if until, exists := muted[s.Name]; exists {
+			flags = append(flags, "muted until "+until.UTC().Format(time.RFC1123))
+		}
+		if len(flags) > 0 {
+			sb.WriteString(strings.Join(flags, ", ") + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	if until, exists := muted[""]; exists {
+		sb.WriteString("all alerts muted until " + until.UTC().Format(time.RFC1123) + "\n")
+	}
+	active := b.notifiers.Active()
+	sb.WriteString(strconv.Itoa(len(active)) + " active alerts\n")
+	for _, a := range active {
+		sb.WriteString("• " + strings.TrimSpace(a.Message) + "\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (b *Bot) uptime(args []string) string {
+	statuses := b.status.Snapshot()
+	if len(args) > 0 {
+		s, found := b.network(args[0])
+		if !found {
+			return "unknown network " + args[0]
+		}
+		statuses = []health.NetworkStatus{s}
+	}
+	var lines []string
+	for _, s := range statuses {
+		if s.Window == 0 {
+			lines = append(lines, s.Name+": no blocks checked yet")
+			continue
+		}
+		// A nil vote still counts as signed on chain, so it is part of
+		// the signed blocks, not listed beside them.
+		signed := s.Signed + s.NilVotes
+		percent := float64(signed) / float64(s.Window) * 100
+		line := s.Name + ": " + strconv.FormatFloat(percent, 'f', 1, 64) + "% of the last " + strconv.Itoa(s.Window) + " blocks signed (" +
+			strconv.Itoa(signed) + " signed including " + strconv.Itoa(s.NilVotes) + " nil, " + strconv.Itoa(s.Missed) + " missed)"
+		if s.MaxMissed > 0 {
+			line += "\nslashing window: " + strconv.FormatInt(s.MissedCounter, 10) + " of " + strconv.FormatInt(s.MaxMissed, 10) + " missed blocks allowed"
+		}
+		if s.Tombstoned {
+			line += "\ntombstoned"
+		} else if s.Jailed {
+			line += "\njailed"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func (b *Bot) mute(args []string) string {
+	if len(args) != 2 {
+		return "usage: /mute <network|all> <duration>"
+	}
+	name, ok := b.target(args[0])
+	if !ok {
+		return "unknown network " + args[0]
+	}
+	d, err := time.ParseDuration(args[1])
+	if err != nil || d <= 0 {
+		return "invalid duration " + args[1] + ", use e.g. 30m or 2h"
+	}
+	until := time.Now().Add(d)
+	b.notifiers.Mute(name, until)
+	return "🔇 muted " + args[0] + " until " + until.UTC().Format(time.RFC1123)
+}
+
+func (b *Bot) unmute(args []string) string {
+	if len(args) != 1 {
+		return "usage: /unmute <network|all>"
+	}
+	name, ok := b.target(args[0])
+	if !ok {
+		return "unknown network " + args[0]
+	}
+	b.notifiers.Unmute(name)
+	return "🔔 unmuted " + args[0]
+}
+
+func (b *Bot) ack(args []string) string {
+	name := ""
+	if len(args) > 0 {
+		var ok bool
+		if name, ok = b.target(args[0]); !ok {
+			return "unknown network " + args[0]
+		}
+	}
+	acked := b.notifiers.Ack(name)
+	if len(acked) == 0 {
+		return "no active alerts to acknowledge"
+	}
+	lines := []string{"✅ acknowledged " + strconv.Itoa(len(acked)) + " alerts"}
+	for _, a := range acked {
+		lines = append(lines, "• "+strings.TrimSpace(a.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// target resolves a command argument to a network name, "" for all.
+func (b *Bot) target(arg string) (string, bool) {
+	if arg == "all" {
+		return "", true
+	}
+	s, found := b.network(arg)
+	return s.Name, found
+}
+
+// network finds a network by name or chain id.
+func (b *Bot) network(arg string) (health.NetworkStatus, bool) {
+	for _, s := range b.status.Snapshot() {
+		if s.Name == arg || s.ChainId == arg {
+			return s, true
+		}
+	}
+	return health.NetworkStatus{}, false
+}
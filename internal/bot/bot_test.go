@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/internal/health"
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestBotCommands(t *testing.T) {
+	replies := make(chan reply, 4)
+	served := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/botkey/getUpdates":
+			resp := updatesResponse{Ok: true}
+			if !served {
+				served = true
+				now := time.Now().Unix()
+				resp.Result = []update{
+					{UpdateId: 1, Message: &message{Date: now, Chat: chat{Id: 7}, Text: "/mute mainnet 30m"}},
+					{UpdateId: 2, Message: &message{Date: now, Chat: chat{Id: 42}, Text: "/mute@penpalbot mainnet 30m"}},
+				}
+			} else {
+				time.Sleep(20 * time.Millisecond)
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/botkey/sendMessage":
+			var rep reply
+			_ = json.NewDecoder(r.Body).Decode(&rep)
+			replies <- rep
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	status := health.NewRegistry()
+	status.Register("mainnet", "chain-1", "ADDR", 0)
+	status.Update("mainnet", func(s *health.NetworkStatus) {
+		s.Signed, s.Missed, s.NilVotes, s.Window = 17, 2, 1, 20
+	})
+	notifiers, err := alert.NewRegistry(settings.Config{}, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	var cfg settings.Config
+	cfg.Notifiers.Telegram = settings.Telegram{Key: "key", Chat: "42", Commands: true, ApiUrl: srv.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Watch(ctx, cfg, status, notifiers)
+
+	select {
+	case rep := <-replies:
+		if rep.Chat != 42 || !strings.HasPrefix(rep.Text, "🔇 muted mainnet until") {
+			t.Fatalf("unexpected reply %+v", rep)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no reply to /mute")
+	}
+	if _, muted := notifiers.Muted()["mainnet"]; !muted {
+		t.Fatalf("expected mainnet to be muted")
+	}
+
+	b := newBot("", status, notifiers)
+	if got := b.answer("/uptime chain-1"); got != "mainnet: 90.0% of the last 20 blocks signed (18 signed including 1 nil, 2 missed)" {
+		t.Fatalf("unexpected uptime %q", got)
+	}
+	if got := b.answer("/mute mainnet soon"); !strings.HasPrefix(got, "invalid duration") {
+		t.Fatalf("expected an invalid duration, got %q", got)
+	}
+	if got := b.answer("hello"); got != "" {
+		t.Fatalf("expected no answer to a plain message, got %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short", 10); got != "short" {
+		t.Fatalf("expected short text unchanged, got %q", got)
+	}
+	// "✅" is three bytes, so cutting at 5 bytes would split the second one.
+	if got := truncate("✅✅✅", 5); got != "✅…" {
+		t.Fatalf("expected a cut on a rune boundary, got %q", got)
+	}
+	text := truncate(strings.Repeat("⏰ stall\n", 1000), maxReplyLen)
+	if !utf8.ValidString(text) || len(text) > maxReplyLen+len("…") {
+		t.Fatalf("expected valid utf-8 within the reply limit, got %d bytes", len(text))
+	}
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"net/http"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/internal/health"
+)
+
+type (
+	// Bot answers commands sent to one Telegram bot from its configured chats.
+	Bot struct {
+		api       string
+		chats     map[string]bool
+		client    *http.Client
+		status    *health.Registry
+		notifiers *alert.Registry
+		offset    int64
+	}
+
+	updatesResponse struct {
+		Ok          bool     `json:"ok"`
+		Description string   `json:"description"`
+		Result      []update `json:"result"`
+	}
+
+	update struct {
+		UpdateId int64    `json:"update_id"`
+		Message  *message `json:"message"`
+	}
+
+	message struct {
+		Date int64  `json:"date"`
+		Chat chat   `json:"chat"`
+		Text string `json:"text"`
+	}
+
+	chat struct {
+		Id int64 `json:"id"`
+	}
+
+	reply struct {
+		Chat int64  `json:"chat_id"`
+		Text string `json:"text"`
+	}
+)
@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/settings"
+)
+
+const defaultMissedIntervals = 3
+
+type (
+	peerState struct {
+		name      string
+		failures  int
+		unhealthy bool
+	}
+
+	peerWatcher struct {
+		self   string
+		missed int
+		nodes  []string
+		peers  map[string]*peerState
+		client *http.Client
+	}
+)
+
+// WatchPeers polls the health endpoint of every configured peer each
+// Health.Interval minutes and raises PeerDown alerts for peers that stay
+// unreachable or unhealthy. Only the live instance with the lowest name sends
+// alerts, so a down peer is reported once across the cluster.
+func WatchPeers(cfg settings.Health, alertChan chan<- alert.Alert, client *http.Client) {
+	w := newPeerWatcher(cfg, client)
+	for {
+		time.Sleep(time.Duration(cfg.Interval) * time.Minute)
+		for _, a := range w.check() {
+			alertChan <- a
+		}
+	}
+}
+
+func newPeerWatcher(cfg settings.Health, client *http.Client) *peerWatcher {
+	w := &peerWatcher{self: InstanceName(cfg), missed: cfg.MissedIntervals, nodes: cfg.Nodes, peers: make(map[string]*peerState, len(cfg.Nodes)), client: client}
+	if w.missed == 0 {
+		w.missed = defaultMissedIntervals
+	}
+	for _, node := range cfg.Nodes {
+		w.peers[node] = &peerState{}
+	}
+	return w
+}
+
+// check polls every peer once and returns the alerts to raise. A peer that
+// answers with 503 is alive but unhealthy: it counts towards the missed
+// intervals like an unreachable one, but stays a leader candidate.
+func (w *peerWatcher) check() []alert.Alert {
+	live := []string{w.self}
+	for _, node := range w.nodes {
+		p := w.peers[node]
+		name, healthy, err := getPeer(node, w.client)
+		if err != nil {
+			p.failures++
+			p.unhealthy = false
+			log.Println("Peer unreachable:", node, err)
+			continue
+		}
+		p.name = name
+		live = append(live, name)
+		if !healthy {
+			p.failures++
+			p.unhealthy = true
+			log.Println("Peer unhealthy:", node)
+			continue
+		}
+		p.failures = 0
+		p.unhealthy = false
+	}
+
+	var alerts []alert.Alert
+	leader := isLeader(w.self, live)
+	for _, node := range w.nodes {
+		p := w.peers[node]
+		// The alert registry only sends the first alert and the
+		// recovery of a down peer. Its label changes once its name is
+		// known, so the node identifies the condition.
+		var a alert.Alert
+		switch {
+		case p.failures >= w.missed && leader && p.unhealthy:
+			a = alert.PeerUnhealthy(peerLabel(node, p), p.failures)
+		case p.failures >= w.missed && leader:
+			a = alert.PeerUnreachable(peerLabel(node, p), p.failures)
+		case p.failures == 0:
+			a = alert.PeerRecovered(peerLabel(node, p))
+		default:
+			continue
+		}
+		a.Subject = node
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
+
+// InstanceName is how this penpal identifies itself to its peers. It defaults
+// to the hostname and health port when Health.Name is not set.
+func InstanceName(cfg settings.Health) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "penpal"
+	}
+	return host + ":" + strings.TrimPrefix(cfg.Port, ":")
+}
+
+// isLeader reports whether self sorts first among the live instances.
+func isLeader(self string, live []string) bool {
+	for _, name := range live {
+		if name < self {
+			return false
+		}
+	}
+	return true
+}
+
+func peerLabel(node string, p *peerState) string {
+	if p.name == "" {
+		return node
+	}
+	return p.name + " (" + node + ")"
+}
+
+// getPeer returns the name a peer reports on /healthz and whether it is
+// healthy. A 503 with a status body is an unhealthy peer, not an error.
+func getPeer(node string, client *http.Client) (string, bool, error) {
+	req, err := http.NewRequestWithContext(context.Background(), "GET", strings.TrimSuffix(node, "/")+"/healthz", nil)
+	if err != nil {
+		return "", false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return "", false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	var body statusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", false, fmt.Errorf("invalid status from %d response: %w", resp.StatusCode, err)
+	}
+	healthy := resp.StatusCode == http.StatusOK
+	if body.Name == "" {
+		return node, healthy, nil
+	}
+	return body.Name, healthy, nil
+}
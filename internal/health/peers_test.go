@@ -0,0 +1,96 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/settings"
+)
+
+// peerServer answers /healthz as a penpal instance with that name.
+func peerServer(name string, healthy bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeStatus(w, healthy, statusResponse{Name: name, Healthy: healthy})
+	}))
+}
+
+func TestIsLeader(t *testing.T) {
+	for _, tc := range []struct {
+		self   string
+		live   []string
+		leader bool
+	}{
+		{"monitor-1", []string{"monitor-1"}, true},
+		{"monitor-1", []string{"monitor-1", "monitor-2"}, true},
+		{"monitor-2", []string{"monitor-2", "monitor-1"}, false},
+		{"monitor-2", []string{"monitor-2", "monitor-3"}, true},
+	} {
+		if leader := isLeader(tc.self, tc.live); leader != tc.leader {
+			t.Fatalf("isLeader(%q, %q) = %v", tc.self, tc.live, leader)
+		}
+	}
+}
+
+func TestWatchPeers(t *testing.T) {
+	healthy := peerServer("monitor-3", true)
+	defer healthy.Close()
+	wedged := peerServer("monitor-2", false)
+	defer wedged.Close()
+	dead := peerServer("", true)
+	dead.Close()
+
+	cfg := settings.Health{Name: "monitor-1", MissedIntervals: 2, Nodes: []string{healthy.URL, wedged.URL, dead.URL}}
+	w := newPeerWatcher(cfg, http.DefaultClient)
+	types := func(alerts []alert.Alert) map[string]string {
+		byNode := make(map[string]string)
+		for _, a := range alerts {
+			byNode[a.Subject] = a.Template
+		}
+		return byNode
+	}
+
+	first := types(w.check())
+	if len(first) != 1 || first[healthy.URL] != "peer_recovered" {
+		t.Fatalf("expected no peer down alerts before %d missed intervals, got %v", cfg.MissedIntervals, first)
+	}
+	second := w.check()
+	if byNode := types(second); len(byNode) != 3 || byNode[wedged.URL] != "peer_unhealthy" || byNode[dead.URL] != "peer_unreachable" {
+		t.Fatalf("expected the wedged peer unhealthy and the dead one unreachable, got %v", byNode)
+	}
+	for _, a := range second {
+		if a.Subject == wedged.URL && a.Data.Name != "monitor-2 ("+wedged.URL+")" {
+			t.Fatalf("expected the wedged peer's name in the alert, got %q", a.Data.Name)
+		}
+	}
+
+	// A wedged peer that sorts first is still alive, so it leads.
+	w.self = "monitor-4"
+	if byNode := types(w.check()); len(byNode) != 1 {
+		t.Fatalf("expected only the leader to send peer down alerts, got %v", byNode)
+	}
+}
+
+func TestGetPeer(t *testing.T) {
+	wedged := peerServer("monitor-2", false)
+	defer wedged.Close()
+	name, ok, err := getPeer(wedged.URL, http.DefaultClient)
+	if err != nil || ok || name != "monitor-2" {
+		t.Fatalf("expected an unhealthy peer, got %q %v %v", name, ok, err)
+	}
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode("no upstream")
+	}))
+	defer proxy.Close()
+	if _, _, err = getPeer(proxy.URL, http.DefaultClient); err == nil {
+		t.Fatalf("expected a 503 without a status to be an error")
+	}
+}
@@ -0,0 +1,71 @@
+package health
+
+import (
+	"time"
+)
+
+func NewRegistry() *Registry {
+	return &Registry{networks: make(map[string]*NetworkStatus)}
+}
+
+// Register adds a network to the registry. A network is reported as wedged
+// when its monitor loop has not beaten within staleAfter.
+func (r *Registry) Register(name, chainId, address string, staleAfter time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.networks[name]; !exists {
+		r.order = append(r.order, name)
+	}
+	r.networks[name] = &NetworkStatus{Name: name, ChainId: chainId, Address: address, StaleAfter: staleAfter, LastBeat: time.Now()}
+}
+
+// Beat records that the monitor loop for a network is still making progress.
+func (r *Registry) Beat(name string) {
+	r.Update(name, func(s *NetworkStatus) {
+		s.LastBeat = time.Now()
+	})
+}
+
+// Update applies fn to the status of a network.
+func (r *Registry) Update(name string, fn func(s *NetworkStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, exists := r.networks[name]
+	if !exists {
+		return
+	}
+	fn(s)
+}
+
+// Snapshot returns a copy of every network status in registration order.
+func (r *Registry) Snapshot() []NetworkStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	statuses := make([]NetworkStatus, 0, len(r.order))
+	for _, name := range r.order {
+		statuses = append(statuses, *r.networks[name])
+	}
+	return statuses
+}
+
+func (s NetworkStatus) stale() bool {
+	return s.StaleAfter > 0 && time.Since(s.LastBeat) > s.StaleAfter
+}
+
+func healthy(statuses []NetworkStatus) bool {
+	for _, s := range statuses {
+		if s.stale() {
+			return false
+		}
+	}
+	return true
+}
+
+func ready(statuses []NetworkStatus) bool {
+	for _, s := range statuses {
+		if s.LastCheck.IsZero() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package health
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/internal/metrics"
+	"github.com/cordtus/penpal/settings"
+)
+
+// Serve starts the health HTTP server on cfg.Port. It blocks until the server fails.
+func Serve(cfg settings.Health, reg *Registry, notifiers *alert.Registry) error {
+	srv := &http.Server{
+		Addr:              listenAddr(cfg.Port),
+		Handler:           Handler(cfg, reg, notifiers),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Println("Health server listening on", srv.Addr)
+	return srv.ListenAndServe()
+}
+
+// Handler serves the health endpoints, and /alerts and /silences when
+// notifiers is set.
+func Handler(cfg settings.Health, reg *Registry, notifiers *alert.Registry) http.Handler {
+	name := InstanceName(cfg)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		statuses := reg.Snapshot()
+		writeStatus(w, healthy(statuses), statusResponse{Name: name, Healthy: healthy(statuses), Ready: ready(statuses)})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		statuses := reg.Snapshot()
+		writeStatus(w, ready(statuses), statusResponse{Name: name, Healthy: healthy(statuses), Ready: ready(statuses)})
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		statuses := reg.Snapshot()
+		writeStatus(w, true, statusResponse{Name: name, Healthy: healthy(statuses), Ready: ready(statuses), Networks: statuses})
+	})
+	mux.Handle("/metrics", metrics.Handler())
+	if notifiers != nil {
+		mux.HandleFunc("/alerts", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, notifiers.Conditions())
+		})
+		mux.HandleFunc("/silences", silencesHandler(cfg, notifiers))
+		mux.HandleFunc("/silences/", silencesHandler(cfg, notifiers))
+	}
+	return mux
+}
+
+func writeStatus(w http.ResponseWriter, ok bool, body statusResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Println("Failed to write health response:", err)
+	}
+}
+
+func listenAddr(port string) string {
+	if strings.Contains(port, ":") {
+		return port
+	}
+	return ":" + port
+}
@@ -0,0 +1,108 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/settings"
+)
+
+func TestHandlerReportsWedgedNetwork(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("net", "net-1", "ADDR", time.Minute)
+
+	srv := httptest.NewServer(Handler(settings.Health{Name: "test", Port: "8080"}, reg, nil))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("readyz request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected readyz 503 before first check, got %d", resp.StatusCode)
+	}
+
+	reg.Update("net", func(s *NetworkStatus) { s.LastCheck = time.Now() })
+	resp, err = http.Get(srv.URL + "/readyz")
+	if err != nil {
+		t.Fatalf("readyz request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected readyz 200 after first check, got %d", resp.StatusCode)
+	}
+
+	reg.mu.Lock()
+	reg.networks["net"].LastBeat = time.Now().Add(-2 * time.Minute)
+	reg.mu.Unlock()
+	resp, err = http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("healthz request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected healthz 503 for wedged network, got %d", resp.StatusCode)
+	}
+}
+
+func TestSilencesApi(t *testing.T) {
+	notifiers, err := alert.NewRegistry(settings.Config{Networks: []settings.Network{{Name: "mainnet"}}}, nil)
+	if err != nil {
+		t.Fatalf("NewRegistry returned error: %v", err)
+	}
+	srv := httptest.NewServer(Handler(settings.Health{Port: "8080", Token: "secret"}, NewRegistry(), notifiers))
+	defer srv.Close()
+	readOnly := httptest.NewServer(Handler(settings.Health{Port: "8080"}, NewRegistry(), notifiers))
+	defer readOnly.Close()
+
+	request := func(method, path, token, body string) *http.Response {
+		base := srv.URL
+		if token == "none" {
+			base, token = readOnly.URL, ""
+		}
+		req, _ := http.NewRequest(method, base+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	silence := `{"networks": ["mainnet"], "duration": "2h", "reason": "upgrade"}`
+	if resp := request("POST", "/silences", "wrong", silence); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a wrong token, got %d", resp.StatusCode)
+	}
+	if resp := request("POST", "/silences", "none", silence); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without a configured token, got %d", resp.StatusCode)
+	}
+	if resp := request("POST", "/silences", "secret", `{"duration": "soon"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid silence, got %d", resp.StatusCode)
+	}
+	if resp := request("POST", "/silences", "secret", `{"networks": ["testnet"], "duration": "2h"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown network, got %d", resp.StatusCode)
+	}
+	if resp := request("POST", "/silences", "secret", silence); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	silences := notifiers.Silences()
+	if len(silences) != 1 || silences[0].Reason != "upgrade" {
+		t.Fatalf("unexpected silences %+v", silences)
+	}
+	if resp := request("DELETE", "/silences/"+silences[0].Id, "none", ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without a configured token, got %d", resp.StatusCode)
+	}
+	if resp := request("DELETE", "/silences/"+silences[0].Id, "secret", ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", resp.StatusCode)
+	}
+	if resp := request("DELETE", "/silences/"+silences[0].Id, "secret", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an ended silence, got %d", resp.StatusCode)
+	}
+}
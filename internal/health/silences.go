@@ -0,0 +1,81 @@
+package health
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/settings"
+)
+
+// silencesHandler lists silences on GET /silences, creates one from a
+// settings.Silence on POST /silences and ends one on DELETE /silences/<id>.
+func silencesHandler(cfg settings.Health, notifiers *alert.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/silences"), "/")
+		switch {
+		case r.Method == http.MethodGet && id == "":
+			writeJSON(w, http.StatusOK, notifiers.Silences())
+		case r.Method == http.MethodPost && id == "":
+			if !authorized(cfg, w, r) {
+				return
+			}
+			var s settings.Silence
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&s); err != nil {
+				http.Error(w, "invalid silence: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			silence, err := alert.NewSilence(s, time.Now())
+			if err == nil {
+				err = notifiers.CheckNetworks(silence)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			silence = notifiers.Silence(silence)
+			log.Println("Silence", silence.Id, "created:", silence.Reason, "until", silence.End.Format(time.RFC1123))
+			writeJSON(w, http.StatusCreated, silence)
+		case r.Method == http.MethodDelete && id != "":
+			if !authorized(cfg, w, r) {
+				return
+			}
+			if !notifiers.Unsilence(id) {
+				http.Error(w, "silence "+id+" not found", http.StatusNotFound)
+				return
+			}
+			log.Println("Silence", id, "removed")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// authorized checks the bearer token before a silence changes. Without a
+// token silences are read-only, since behind a proxy every request would
+// look local.
+func authorized(cfg settings.Health, w http.ResponseWriter, r *http.Request) bool {
+	if cfg.Token == "" {
+		http.Error(w, "set health.token to change silences", http.StatusForbidden)
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Println("Failed to write health response:", err)
+	}
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Registry holds the latest status reported by each network monitor.
+	Registry struct {
+		mu       sync.RWMutex
+		order    []string
+		networks map[string]*NetworkStatus
+	}
+
+	NetworkStatus struct {
+		Name       string `json:"name"`
+		ChainId    string `json:"chain_id"`
+		Address    string `json:"address"`
+		Height     int64  `json:"height"`
+		ActiveRpc  string `json:"active_rpc"`
+		Signed     int    `json:"signed"`
+		Missed     int    `json:"missed"`
+		NilVotes   int    `json:"nil_votes"`
+		Window     int    `json:"window"`
+		Alerted    bool   `json:"alerted"`
+		Jailed     bool   `json:"jailed"`
+		Tombstoned bool   `json:"tombstoned"`
+		// MissedCounter is the on-chain missed blocks counter and MaxMissed
+		// the number of misses in the slashing window that leads to jailing.
+		MissedCounter int64         `json:"missed_counter"`
+		MaxMissed     int64         `json:"max_missed"`
+		RpcAlerted    bool          `json:"rpc_alerted"`
+		LastCheck     time.Time     `json:"last_check"`
+		LastBeat      time.Time     `json:"last_beat"`
+		StaleAfter    time.Duration `json:"-"`
+	}
+
+	statusResponse struct {
+		Name     string          `json:"name"`
+		Healthy  bool            `json:"healthy"`
+		Ready    bool            `json:"ready"`
+		Networks []NetworkStatus `json:"networks"`
+	}
+)
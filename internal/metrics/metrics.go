@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	defaultRegistry = &Registry{}
+	labelEscaper    = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+var (
+	networkLabels = []string{"network", "chain_id", "address"}
+
+	MissedBlocks        = NewGauge("penpal_missed_blocks", "Blocks missed in the back-check window.", networkLabels...)
+	NilVotes            = NewGauge("penpal_nil_votes", "Nil votes in the back-check window.", networkLabels...)
+	WindowBlocks        = NewGauge("penpal_window_blocks", "Blocks checked in the back-check window.", networkLabels...)
+	LatestHeight        = NewGauge("penpal_latest_height", "Latest block height seen on the active rpc.", networkLabels...)
+	BlockTimeLag        = NewGauge("penpal_block_time_lag_seconds", "Seconds since the latest block was produced.", networkLabels...)
+	RpcFailovers        = NewCounter("penpal_rpc_failovers_total", "Times the active rpc changed.", networkLabels...)
+	RpcUnavailable      = NewCounter("penpal_rpc_unavailable_total", "Checks where no rpc responded.", networkLabels...)
+	SignerErrors        = NewGauge("penpal_signer_errors", "Error counter reported by the signer.", networkLabels...)
+	SignerCheckpoint    = NewGauge("penpal_signer_checkpoint_index", "Latest checkpoint index reported by the signer.", networkLabels...)
+	SignerCheckpointAge = NewGauge("penpal_signer_checkpoint_age_seconds", "Seconds since the latest signer checkpoint.", networkLabels...)
+	ValidatorJailed     = NewGauge("penpal_validator_jailed", "1 if the validator is jailed.", networkLabels...)
+	ValidatorTombstoned = NewGauge("penpal_validator_tombstoned", "1 if the validator is tombstoned.", networkLabels...)
+	MissedBlocksCounter = NewGauge("penpal_missed_blocks_counter", "On-chain missed blocks counter in the slashing window.", networkLabels...)
+	AlertsSent          = NewCounter("penpal_alerts_sent_total", "Alerts delivered to a notifier.", "notifier", "type")
+	AlertsFailed        = NewCounter("penpal_alerts_failed_total", "Alerts dropped after exhausting retries.", "notifier", "type")
+)
+
+func NewGauge(name, help string, labels ...string) GaugeVec {
+	return GaugeVec{r: defaultRegistry, f: defaultRegistry.register(name, help, "gauge", labels)}
+}
+
+func NewCounter(name, help string, labels ...string) CounterVec {
+	return CounterVec{r: defaultRegistry, f: defaultRegistry.register(name, help, "counter", labels)}
+}
+
+func (g GaugeVec) Set(value float64, labelValues ...string) {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.f.sample(labelValues).value = value
+}
+
+func (c CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.f.sample(labelValues).value += delta
+}
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := defaultRegistry.write(w); err != nil {
+			log.Println("Failed to write metrics:", err)
+		}
+	})
+}
+
+func (r *Registry) register(name, help, kind string, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := &family{name: name, help: help, kind: kind, labels: labels, samples: make(map[string]*sample)}
+	r.families = append(r.families, f)
+	return f
+}
+
+func (f *family) sample(labelValues []string) *sample {
+	values := make([]string, len(f.labels))
+	copy(values, labelValues)
+	key := strings.Join(values, "\xff")
+	s, exists := f.samples[key]
+	if !exists {
+		s = &sample{labelValues: values}
+		f.samples[key] = s
+		f.order = append(f.order, key)
+	}
+	return s
+}
+
+func (r *Registry) write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.families {
+		if len(f.order) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind); err != nil {
+			return err
+		}
+		for _, key := range f.order {
+			s := f.samples[key]
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labels, s.labelValues), strconv.FormatFloat(s.value, 'g', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=\"" + labelEscaper.Replace(values[i]) + "\""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
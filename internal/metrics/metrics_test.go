@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := &Registry{}
+	g := GaugeVec{r: r, f: r.register("test_gauge", "A gauge.", "gauge", []string{"network"})}
+	c := CounterVec{r: r, f: r.register("test_total", "A counter.", "counter", []string{"notifier"})}
+	r.register("test_unused", "Never set.", "gauge", nil)
+
+	g.Set(3, `net "one"`)
+	c.Inc("telegram")
+	c.Add(2, "telegram")
+
+	var out strings.Builder
+	if err := r.write(&out); err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	expected := `# HELP test_gauge A gauge.
+# TYPE test_gauge gauge
+test_gauge{network="net \"one\""} 3
+# HELP test_total A counter.
+# TYPE test_total counter
+test_total{notifier="telegram"} 3
+`
+	if out.String() != expected {
+		t.Fatalf("unexpected exposition:\n%s", out.String())
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"sync"
+)
+
+type (
+	// Registry is a minimal Prometheus text-format collector.
+	Registry struct {
+		mu       sync.Mutex
+		families []*family
+	}
+
+	family struct {
+		name    string
+		help    string
+		kind    string
+		labels  []string
+		order   []string
+		samples map[string]*sample
+	}
+
+	sample struct {
+		labelValues []string
+		value       float64
+	}
+
+	GaugeVec struct {
+		r *Registry
+		f *family
+	}
+
+	CounterVec struct {
+		r *Registry
+		f *family
+	}
+)
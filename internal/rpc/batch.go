@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// maxBlockchainRange is the most headers CometBFT returns from one /blockchain call.
+const maxBlockchainRange = 20
+
+// GetBlocksFromHeights fetches the blocks at heights with JSON-RPC 2.0 batch
+// requests of at most batchSize calls each. Heights the node returned an
+// error for are left out of the result.
+func GetBlocksFromHeights(heights []int64, batchSize int, url string, client *http.Client) (map[int64]Block, error) {
+	return getBatchByHeight[Block]("block", heights, batchSize, url, client)
+}
+
+// GetCommitsFromHeights fetches the commits at heights the same way as
+// GetBlocksFromHeights. Commits are much smaller than blocks on busy chains.
+func GetCommitsFromHeights(heights []int64, batchSize int, url string, client *http.Client) (map[int64]Commit, error) {
+	return getBatchByHeight[Commit]("commit", heights, batchSize, url, client)
+}
+
+// GetBlockHeaders returns the headers between minHeight and maxHeight
+// inclusive using /blockchain, split into ranges the node will serve.
+func GetBlockHeaders(minHeight, maxHeight int64, url string, client *http.Client) ([]BlockMeta, error) {
+	var metas []BlockMeta
+	for low := minHeight; low <= maxHeight; low += maxBlockchainRange {
+		high := low + maxBlockchainRange - 1
+		if high > maxHeight {
+			high = maxHeight
+		}
+		var responseData blockchainInfo
+		err := getByUrlAndUnmarshall(&responseData, url+"/blockchain?minHeight="+strconv.FormatInt(low, 10)+"&maxHeight="+strconv.FormatInt(high, 10), client)
+		if err != nil {
+			return metas, err
+		}
+		if responseData.Error != nil {
+			return metas, fmt.Errorf("blockchain error: %v", responseData.Error)
+		}
+		metas = append(metas, responseData.Result.BlockMetas...)
+	}
+	return metas, nil
+}
+
+func getBatchByHeight[T any](method string, heights []int64, batchSize int, url string, client *http.Client) (map[int64]T, error) {
+	results := make(map[int64]T, len(heights))
+	for start := 0; start < len(heights); start += batchSize {
+		end := start + batchSize
+		if end > len(heights) {
+			end = len(heights)
+		}
+		calls := make([]batchRequest, 0, end-start)
+		for _, h := range heights[start:end] {
+			calls = append(calls, batchRequest{JsonRpc: "2.0", Id: h, Method: method, Params: map[string]string{"height": strconv.FormatInt(h, 10)}})
+		}
+		var responses []json.RawMessage
+		if err := postBatch(&responses, calls, url, client); err != nil {
+			return results, err
+		}
+		for _, raw := range responses {
+			var r batchResponse
+			if err := json.Unmarshal(raw, &r); err != nil || r.Error != nil {
+				continue
+			}
+			var result T
+			if err := json.Unmarshal(raw, &result); err != nil {
+				continue
+			}
+			results[r.Id] = result
+		}
+	}
+	return results, nil
+}
+
+func postBatch(data interface{}, calls []batchRequest, url string, client *http.Client) error {
+	body, err := json.Marshal(calls)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// Nodes that don't support batching answer with a single error object.
+	if len(bytes.TrimSpace(respBody)) > 0 && bytes.TrimSpace(respBody)[0] != '[' {
+		return fmt.Errorf("batch request rejected: %s", bytes.TrimSpace(respBody))
+	}
+	return json.Unmarshal(respBody, data)
+}
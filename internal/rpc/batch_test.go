@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetBlocksFromHeights(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var calls []batchRequest
+		if err := json.NewDecoder(r.Body).Decode(&calls); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var responses []string
+		for _, c := range calls {
+			if c.Params["height"] == "3" {
+				responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"error":{"code":-32603,"message":"height 3 is not available"}}`, c.Id))
+				continue
+			}
+			responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{"block":{"header":{"height":"%s"}}}}`, c.Id, c.Params["height"]))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(responses, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	blocks, err := GetBlocksFromHeights([]int64{5, 4, 3, 2, 1}, 2, srv.URL, srv.Client())
+	if err != nil {
+		t.Fatalf("GetBlocksFromHeights returned error: %v", err)
+	}
+	if requests != 3 {
+		t.Fatalf("expected 3 batch requests, got %d", requests)
+	}
+	if len(blocks) != 4 {
+		t.Fatalf("expected 4 blocks, got %d", len(blocks))
+	}
+	if _, exists := blocks[3]; exists {
+		t.Fatal("expected height 3 to be skipped")
+	}
+	if blocks[5].Result.Block.Header.Height != "5" {
+		t.Fatalf("expected height 5, got %q", blocks[5].Result.Block.Header.Height)
+	}
+}
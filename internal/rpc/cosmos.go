@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const ed25519PubKeyType = "/cosmos.crypto.ed25519.PubKey"
+
+// FindValidator pages through the staking module's validators on the REST
+// api and returns the one whose consensus key hashes to the hex address.
+func FindValidator(api string, address string, client *http.Client) (Validator, error) {
+	address = strings.ToUpper(address)
+	nextKey := ""
+	for {
+		query := "/cosmos/staking/v1beta1/validators?pagination.limit=200"
+		if nextKey != "" {
+			query += "&pagination.key=" + url.QueryEscape(nextKey)
+		}
+		var responseData validatorsResponse
+		if err := getByUrlAndUnmarshall(&responseData, strings.TrimSuffix(api, "/")+query, client); err != nil {
+			return Validator{}, err
+		}
+		for _, v := range responseData.Validators {
+			consAddress, err := v.ConsensusAddress()
+			if err == nil && consAddress == address {
+				return v, nil
+			}
+		}
+		if responseData.Pagination.NextKey == "" {
+			return Validator{}, errors.New("no validator with consensus address " + address)
+		}
+		nextKey = responseData.Pagination.NextKey
+	}
+}
+
+func GetValidator(api string, operator string, client *http.Client) (Validator, error) {
+	var responseData validatorResponse
+	err := getByUrlAndUnmarshall(&responseData, strings.TrimSuffix(api, "/")+"/cosmos/staking/v1beta1/validators/"+operator, client)
+	if err == nil && responseData.Validator.OperatorAddress == "" {
+		err = fmt.Errorf("validator %s not found: %s", operator, responseData.Message)
+	}
+	return responseData.Validator, err
+}
+
+func GetSigningInfo(api string, consAddress string, client *http.Client) (SigningInfo, error) {
+	var responseData signingInfoResponse
+	err := getByUrlAndUnmarshall(&responseData, strings.TrimSuffix(api, "/")+"/cosmos/slashing/v1beta1/signing_infos/"+consAddress, client)
+	if err == nil && responseData.ValSigningInfo.Address == "" {
+		err = fmt.Errorf("signing info for %s not found: %s", consAddress, responseData.Message)
+	}
+	return responseData.ValSigningInfo, err
+}
+
+func GetSlashingParams(api string, client *http.Client) (SlashingParams, error) {
+	var responseData slashingParamsResponse
+	err := getByUrlAndUnmarshall(&responseData, strings.TrimSuffix(api, "/")+"/cosmos/slashing/v1beta1/params", client)
+	if err == nil && responseData.Params.SignedBlocksWindow == "" {
+		err = errors.New("slashing params missing signed_blocks_window")
+	}
+	return responseData.Params, err
+}
+
+// MaxMissedBlocks is how many blocks a validator can miss within the signed
+// blocks window before it is jailed, computed the way x/slashing does.
+func (p SlashingParams) MaxMissedBlocks() (window int64, maxMissed int64, err error) {
+	window, err = strconv.ParseInt(p.SignedBlocksWindow, 10, 64)
+	if err != nil {
+		return
+	}
+	minSigned, err := strconv.ParseFloat(p.MinSignedPerWindow, 64)
+	if err != nil {
+		return
+	}
+	maxMissed = window - int64(math.Round(float64(window)*minSigned))
+	return
+}
+
+// ConsensusAddress returns the upper case hex address CometBFT uses for the
+// validator's ed25519 consensus key.
+func (v Validator) ConsensusAddress() (string, error) {
+	if v.ConsensusPubkey.Type != ed25519PubKeyType {
+		return "", errors.New("unsupported consensus key type " + v.ConsensusPubkey.Type)
+	}
+	key, err := base64.StdEncoding.DecodeString(v.ConsensusPubkey.Key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	return strings.ToUpper(hex.EncodeToString(sum[:20])), nil
+}
+
+// ValconsAddress encodes a hex consensus address with the valcons prefix of
+// the chain the operator address belongs to.
+func ValconsAddress(operator string, address string) (string, error) {
+	prefix, err := bech32Prefix(operator)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(prefix, "valoper") {
+		return "", errors.New("unexpected operator address prefix " + prefix)
+	}
+	raw, err := hex.DecodeString(address)
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(strings.TrimSuffix(prefix, "valoper")+"valcons", raw)
+}
@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"testing"
+)
+
+func TestConsensusAddress(t *testing.T) {
+	var v Validator
+	v.ConsensusPubkey.Type = ed25519PubKeyType
+	v.ConsensusPubkey.Key = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	address, err := v.ConsensusAddress()
+	if err != nil {
+		t.Fatalf("ConsensusAddress returned error: %v", err)
+	}
+	if address != "630DCD2966C4336691125448BBB25B4FF412A49C" {
+		t.Fatalf("unexpected consensus address %s", address)
+	}
+}
+
+func TestValconsAddress(t *testing.T) {
+	valcons, err := ValconsAddress("cosmosvaloper1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqkh6mvu", "0123456789ABCDEF0123456789ABCDEF01234567")
+	if err != nil {
+		t.Fatalf("ValconsAddress returned error: %v", err)
+	}
+	if valcons != "cosmosvalcons1qy352euf40x77qfrg4ncn27dauqjx3t8cp02hv" {
+		t.Fatalf("unexpected valcons address %s", valcons)
+	}
+	if _, err := ValconsAddress("cosmos1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqnrql8a", "00"); err == nil {
+		t.Fatal("expected an error for a non-operator prefix")
+	}
+}
@@ -10,16 +10,16 @@ import (
 )
 
 func GetLatestHeight(url string, client *http.Client) (chainID string, height string, err error) {
-	block, err := getLatestBlock(url, client)
+	block, err := GetLatestBlock(url, client)
 	return block.Result.Block.Header.ChainID, block.Result.Block.Header.Height, err
 }
 
 func GetLatestBlockTime(url string, client *http.Client) (string, time.Time, error) {
-	block, err := getLatestBlock(url, client)
+	block, err := GetLatestBlock(url, client)
 	return block.Result.Block.Header.ChainID, block.Result.Block.Header.Time, err
 }
 
-func getLatestBlock(url string, client *http.Client) (responseData Block, err error) {
+func GetLatestBlock(url string, client *http.Client) (responseData Block, err error) {
 	err = getByUrlAndUnmarshall(&responseData, url+"/block", client)
 	return
 }
@@ -29,6 +29,11 @@ func GetBlockFromHeight(height string, url string, client *http.Client) (respons
 	return
 }
 
+func GetCommitFromHeight(height string, url string, client *http.Client) (responseData Commit, err error) {
+	err = getByUrlAndUnmarshall(&responseData, url+"/commit?height="+height, client)
+	return
+}
+
 func getByUrlAndUnmarshall(data interface{}, url string, client *http.Client) (err error) {
 	r := &strings.Reader{}
 	req, err := http.NewRequestWithContext(context.Background(), "GET", url, r)
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsPingInterval = 30 * time.Second
+	wsReadTimeout  = 90 * time.Second
+)
+
+// SubscribeNewBlocks subscribes to NewBlock events on the CometBFT websocket of
+// url and sends each block on blocks. It returns when ctx is cancelled or the
+// connection fails; callers are expected to reconnect.
+func SubscribeNewBlocks(ctx context.Context, url string, blocks chan<- Block) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, websocketUrl(url), nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	req := subscribeRequest{JsonRpc: "2.0", Method: "subscribe", Id: 1}
+	req.Params.Query = "tm.event='NewBlock'"
+	if err = conn.WriteJSON(req); err != nil {
+		return err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				_ = conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+			}
+		}
+	}()
+
+	for {
+		var event newBlockEvent
+		if err = conn.ReadJSON(&event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if event.Error != nil {
+			return fmt.Errorf("subscription error: %v", event.Error)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		// The first response only acknowledges the subscription.
+		if event.Result.Data.Value.Block == nil {
+			continue
+		}
+		var block Block
+		block.Result.Block = *event.Result.Data.Value.Block
+		select {
+		case blocks <- block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func websocketUrl(url string) string {
+	url = strings.TrimSuffix(url, "/")
+	if strings.HasPrefix(url, "https://") {
+		url = "wss://" + strings.TrimPrefix(url, "https://")
+	} else if strings.HasPrefix(url, "http://") {
+		url = "ws://" + strings.TrimPrefix(url, "http://")
+	}
+	return url + "/websocket"
+}
@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscribeNewBlocks(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/websocket" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var req subscribeRequest
+		if err := conn.ReadJSON(&req); err != nil || req.Params.Query != "tm.event='NewBlock'" {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"result":{"query":"tm.event='NewBlock'","data":{"type":"tendermint/event/NewBlock","value":{"block":{"header":{"chain_id":"test-1","height":"12"},"last_commit":{"signatures":[{"validator_address":"ADDR"}]}}}}}}`))
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	blocks := make(chan Block, 1)
+	go func() { _ = SubscribeNewBlocks(ctx, srv.URL, blocks) }()
+
+	select {
+	case block := <-blocks:
+		if block.Result.Block.Header.Height != "12" {
+			t.Fatalf("expected height 12, got %s", block.Result.Block.Header.Height)
+		}
+		if len(block.Result.Block.LastCommit.Signatures) != 1 || block.Result.Block.LastCommit.Signatures[0].ValidatorAddress != "ADDR" {
+			t.Fatalf("unexpected signatures %+v", block.Result.Block.LastCommit.Signatures)
+		}
+	case <-ctx.Done():
+		t.Fatal("no block received")
+	}
+}
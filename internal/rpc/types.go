@@ -4,22 +4,148 @@ import (
 	"time"
 )
 
+// Values of block_id_flag in commit signatures.
+const (
+	BlockIDFlagAbsent = 1
+	BlockIDFlagCommit = 2
+	BlockIDFlagNil    = 3
+)
+
 type (
 	Block struct {
 		Error  interface{} `json:"error"`
 		Result struct {
-			Block struct {
-				Header struct {
-					ChainID string    `json:"chain_id"`
-					Height  string    `json:"height"`
-					Time    time.Time `json:"time"`
-				} `json:"header"`
-				LastCommit struct {
-					Signatures []struct {
-						ValidatorAddress string `json:"validator_address"`
-					} `json:"signatures"`
-				} `json:"last_commit"`
-			} `json:"block"`
+			Block BlockData `json:"block"`
+		} `json:"result"`
+	}
+
+	BlockData struct {
+		Header     Header     `json:"header"`
+		LastCommit CommitData `json:"last_commit"`
+	}
+
+	Header struct {
+		ChainID string    `json:"chain_id"`
+		Height  string    `json:"height"`
+		Time    time.Time `json:"time"`
+	}
+
+	Commit struct {
+		Error  interface{} `json:"error"`
+		Result struct {
+			SignedHeader struct {
+				Header Header     `json:"header"`
+				Commit CommitData `json:"commit"`
+			} `json:"signed_header"`
+			Canonical bool `json:"canonical"`
+		} `json:"result"`
+	}
+
+	CommitData struct {
+		Height     string      `json:"height"`
+		Round      int         `json:"round"`
+		Signatures []Signature `json:"signatures"`
+	}
+
+	Signature struct {
+		BlockIDFlag      int       `json:"block_id_flag"`
+		ValidatorAddress string    `json:"validator_address"`
+		Timestamp        time.Time `json:"timestamp"`
+		Signature        string    `json:"signature"`
+	}
+
+	BlockMeta struct {
+		Header Header `json:"header"`
+	}
+
+	blockchainInfo struct {
+		Error  interface{} `json:"error"`
+		Result struct {
+			LastHeight string      `json:"last_height"`
+			BlockMetas []BlockMeta `json:"block_metas"`
+		} `json:"result"`
+	}
+
+	batchRequest struct {
+		JsonRpc string            `json:"jsonrpc"`
+		Id      int64             `json:"id"`
+		Method  string            `json:"method"`
+		Params  map[string]string `json:"params"`
+	}
+
+	batchResponse struct {
+		Id    int64       `json:"id"`
+		Error interface{} `json:"error"`
+	}
+
+	Validator struct {
+		OperatorAddress string `json:"operator_address"`
+		ConsensusPubkey struct {
+			Type string `json:"@type"`
+			Key  string `json:"key"`
+		} `json:"consensus_pubkey"`
+		Jailed      bool   `json:"jailed"`
+		Status      string `json:"status"`
+		Description struct {
+			Moniker string `json:"moniker"`
+		} `json:"description"`
+	}
+
+	SigningInfo struct {
+		Address             string    `json:"address"`
+		StartHeight         string    `json:"start_height"`
+		IndexOffset         string    `json:"index_offset"`
+		JailedUntil         time.Time `json:"jailed_until"`
+		Tombstoned          bool      `json:"tombstoned"`
+		MissedBlocksCounter string    `json:"missed_blocks_counter"`
+	}
+
+	SlashingParams struct {
+		SignedBlocksWindow      string `json:"signed_blocks_window"`
+		MinSignedPerWindow      string `json:"min_signed_per_window"`
+		DowntimeJailDuration    string `json:"downtime_jail_duration"`
+		SlashFractionDowntime   string `json:"slash_fraction_downtime"`
+		SlashFractionDoubleSign string `json:"slash_fraction_double_sign"`
+	}
+
+	slashingParamsResponse struct {
+		Params SlashingParams `json:"params"`
+	}
+
+	validatorsResponse struct {
+		Validators []Validator `json:"validators"`
+		Pagination struct {
+			NextKey string `json:"next_key"`
+		} `json:"pagination"`
+	}
+
+	validatorResponse struct {
+		Message   string    `json:"message"`
+		Validator Validator `json:"validator"`
+	}
+
+	signingInfoResponse struct {
+		Message        string      `json:"message"`
+		ValSigningInfo SigningInfo `json:"val_signing_info"`
+	}
+
+	subscribeRequest struct {
+		JsonRpc string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Id      int    `json:"id"`
+		Params  struct {
+			Query string `json:"query"`
+		} `json:"params"`
+	}
+
+	newBlockEvent struct {
+		Error  interface{} `json:"error"`
+		Result struct {
+			Data struct {
+				Value struct {
+					Block *BlockData `json:"block"`
+				} `json:"value"`
+			} `json:"data"`
 		} `json:"result"`
 	}
 )
@@ -0,0 +1,181 @@
+package scan
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/internal/health"
+	"github.com/cordtus/penpal/internal/metrics"
+	"github.com/cordtus/penpal/internal/rpc"
+	"github.com/cordtus/penpal/settings"
+)
+
+const (
+	// slashingParamsRefresh is how often slashing params are re-read, since
+	// governance can change them.
+	slashingParamsRefresh = time.Hour
+	// blockTimeSample is the number of recent headers used to estimate block time.
+	blockTimeSample = 20
+)
+
+// monitorChain polls the staking and slashing modules over the network's REST
+// api and alerts when the validator is jailed or tombstoned, or has used up a
+// configured share of its missed blocks budget. Like the signing checks it
+// reports the current state every time, and the alert registry only sends
+// what changed.
+func monitorChain(network settings.Network, alertChan chan<- alert.Alert, client *http.Client, status *health.Registry) {
+	lastLevel := 0
+	var operator, valcons string
+	var maxMissed int64
+	var paramsFetched time.Time
+	labels := []string{network.Name, network.ChainId, network.Address}
+
+	for {
+		// Resolve the operator and valcons addresses once, then query them directly
+		if operator == "" {
+			validator, err := rpc.FindValidator(network.Api, network.Address, client)
+			if err != nil {
+				log.Println("Failed to find validator", network.Address, "on", network.Api, ":", err)
+				time.Sleep(time.Duration(network.Interval) * time.Second)
+				continue
+			}
+			valcons, err = rpc.ValconsAddress(validator.OperatorAddress, strings.ToUpper(network.Address))
+			if err != nil {
+				log.Println("Failed to derive valcons address for", validator.OperatorAddress, ":", err)
+				time.Sleep(time.Duration(network.Interval) * time.Second)
+				continue
+			}
+			operator = validator.OperatorAddress
+			log.Println("Watching chain state for", network.Name, "as", operator, valcons)
+		}
+
+		validator, err := rpc.GetValidator(network.Api, operator, client)
+		if err != nil {
+			log.Println("Failed to fetch validator", operator, ":", err)
+			time.Sleep(time.Duration(network.Interval) * time.Second)
+			continue
+		}
+		info, err := rpc.GetSigningInfo(network.Api, valcons, client)
+		if err != nil {
+			log.Println("Failed to fetch signing info", valcons, ":", err)
+			time.Sleep(time.Duration(network.Interval) * time.Second)
+			continue
+		}
+
+		// A tombstoned validator is jailed for good, with a JailedUntil far
+		// in the future, so the tombstone alert stands in for the jail one.
+		jailed := validator.Jailed || info.JailedUntil.After(time.Now())
+		switch {
+		case info.Tombstoned:
+			notify(alertChan, network, alert.Tombstoned(network.Name))
+		case jailed:
+			notify(alertChan, network, alert.Jailed(network.Name, info.JailedUntil))
+		default:
+			notify(alertChan, network, alert.Unjailed(network.Name))
+		}
+
+		if len(network.SlashingThresholds) > 0 && time.Since(paramsFetched) > slashingParamsRefresh {
+			params, err := rpc.GetSlashingParams(network.Api, client)
+			if err == nil {
+				_, maxMissed, err = params.MaxMissedBlocks()
+			}
+			if err != nil {
+				log.Println("Failed to fetch slashing params for", network.ChainId, ":", err)
+			} else {
+				paramsFetched = time.Now()
+			}
+		}
+
+		missedCounter, err := strconv.ParseInt(info.MissedBlocksCounter, 10, 64)
+		if err != nil {
+			missedCounter = 0
+		}
+		metrics.MissedBlocksCounter.Set(float64(missedCounter), labels...)
+
+		// Alert each time the missed blocks counter crosses a higher share of the
+		// jail budget, and clear once it falls below the lowest threshold. The
+		// block time is only estimated when the level rises.
+		if maxMissed > 0 && !jailed {
+			level := slashingLevel(missedCounter, maxMissed, network.SlashingThresholds)
+			if level > lastLevel {
+				remaining := maxMissed - missedCounter
+				blockTime, err := averageBlockTime(network, client)
+				if err != nil {
+					log.Println("Failed to estimate block time for", network.ChainId, ":", err)
+				}
+				notify(alertChan, network, alert.SlashingWindow(network.Name, level, missedCounter, maxMissed, time.Duration(remaining)*blockTime))
+			} else if level == 0 {
+				notify(alertChan, network, alert.SlashingWindowCleared(network.Name, missedCounter, maxMissed))
+			}
+			lastLevel = level
+		}
+
+		metrics.ValidatorJailed.Set(boolGauge(jailed), labels...)
+		metrics.ValidatorTombstoned.Set(boolGauge(info.Tombstoned), labels...)
+		status.Update(network.Name, func(s *health.NetworkStatus) {
+			s.Jailed = jailed
+			s.Tombstoned = info.Tombstoned
+			s.MissedCounter = missedCounter
+			s.MaxMissed = maxMissed
+		})
+
+		time.Sleep(time.Duration(network.Interval) * time.Second)
+	}
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// slashingLevel returns the highest threshold percentage of maxMissed that
+// missed has reached, or 0 if it is below all of them.
+func slashingLevel(missed, maxMissed int64, thresholds []int) int {
+	level := 0
+	for _, threshold := range thresholds {
+		if missed*100 >= int64(threshold)*maxMissed && threshold > level {
+			level = threshold
+		}
+	}
+	return level
+}
+
+// averageBlockTime estimates the block time from the most recent headers.
+func averageBlockTime(network settings.Network, client *http.Client) (time.Duration, error) {
+	activeRpc, err := getWorkingRpc(network.Rpcs, "", client)
+	if err != nil {
+		return 0, err
+	}
+	_, heightStr, err := rpc.GetLatestHeight(activeRpc, client)
+	if err != nil {
+		return 0, err
+	}
+	height, err := strconv.ParseInt(heightStr, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	low := height - blockTimeSample + 1
+	if low < 1 {
+		low = 1
+	}
+	headers, err := rpc.GetBlockHeaders(low, height, activeRpc, client)
+	if err != nil {
+		return 0, err
+	}
+	if len(headers) < 2 {
+		return 0, errors.New("not enough headers to estimate block time")
+	}
+	// /blockchain returns headers newest first
+	newest, oldest := headers[0].Header, headers[len(headers)-1].Header
+	if newest.Time.Before(oldest.Time) {
+		newest, oldest = oldest, newest
+	}
+	return newest.Time.Sub(oldest.Time) / time.Duration(len(headers)-1), nil
+}
@@ -0,0 +1,472 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/internal/bot"
+	"github.com/cordtus/penpal/internal/health"
+	"github.com/cordtus/penpal/internal/metrics"
+	"github.com/cordtus/penpal/internal/rpc"
+	"github.com/cordtus/penpal/settings"
+	"github.com/cordtus/penpal/state"
+)
+
+const defaultBatchSize = 10
+
+// Monitor watches every configured network. Notifiers passed in receive every
+// alert alongside the sinks from the config. State is kept in the config's
+// state database.
+func Monitor(cfg settings.Config, notifiers ...alert.Notifier) {
+	store, err := state.Open(cfg.State)
+	if err != nil {
+		log.Fatal("Failed to open state database: ", err)
+	}
+	MonitorWith(cfg, store, notifiers...)
+}
+
+// MonitorWith is Monitor keeping its state in store, restoring the signing
+// windows, alert conditions and signer baselines saved there on startup.
+func MonitorWith(cfg settings.Config, store state.Store, notifiers ...alert.Notifier) {
+	alertChan := make(chan alert.Alert)
+	client := &http.Client{Timeout: time.Second * 10}
+	registry, err := alert.NewRegistry(cfg, client)
+	if err != nil {
+		log.Fatal("Invalid alert templates: ", err)
+	}
+	for _, n := range notifiers {
+		registry.Register(n)
+	}
+	restoreAlerts(store, registry)
+	go saveAlerts(store, registry)
+	go alert.Watch(alertChan, cfg, registry)
+
+	status := health.NewRegistry()
+	for _, network := range cfg.Networks {
+		status.Register(network.Name, network.ChainId, network.Address, staleAfter(network))
+	}
+	if cfg.Health.Port != "" {
+		go func() {
+			if err := health.Serve(cfg.Health, status, registry); err != nil {
+				log.Println("Health server stopped:", err)
+			}
+		}()
+	}
+	if len(cfg.Health.Nodes) > 0 {
+		go health.WatchPeers(cfg.Health, alertChan, client)
+	}
+	bot.Watch(context.Background(), cfg, status, registry)
+
+	for _, network := range cfg.Networks {
+		go monitorNetwork(network, alertChan, client, status, store)
+		if network.SignerMetrics != "" {
+			go monitorSigner(network, alertChan, client, store)
+		}
+		if network.Api != "" {
+			go monitorChain(network, alertChan, client, status)
+		}
+	}
+
+	select {}
+}
+
+// getWorkingRpc tries the preferred RPC, usually the one that worked last,
+// then each RPC in the list and returns the first one that responds.
+func getWorkingRpc(rpcs []string, preferred string, client *http.Client) (string, error) {
+	order := make([]string, 0, len(rpcs))
+	for _, url := range rpcs {
+		if url == preferred {
+			order = append(order, url)
+		}
+	}
+	for _, url := range rpcs {
+		if url != preferred {
+			order = append(order, url)
+		}
+	}
+	var lastErr error
+	for _, url := range order {
+		_, _, err := rpc.GetLatestHeight(url, client)
+		if err == nil {
+			return url, nil
+		}
+		lastErr = err
+		log.Println("RPC unreachable:", url, err)
+	}
+	return "", lastErr
+}
+
+// staleAfter is how long a network loop may go without reporting before the
+// health server considers it wedged.
+func staleAfter(network settings.Network) time.Duration {
+	stale := 5 * time.Duration(network.Interval) * time.Second
+	if stale < 5*time.Minute {
+		stale = 5 * time.Minute
+	}
+	return stale
+}
+
+type networkMonitor struct {
+	network   settings.Network
+	alertChan chan<- alert.Alert
+	client    *http.Client
+	status    *health.Registry
+	store     state.Store
+	labels    []string
+
+	lastRpc string
+	// noBatch is set once the active rpc rejects batch requests.
+	noBatch bool
+
+	// window holds the signing result of each height fetched by polling or
+	// received over the websocket.
+	window *signingWindow
+}
+
+func monitorNetwork(network settings.Network, alertChan chan<- alert.Alert, client *http.Client, status *health.Registry, store state.Store) {
+	m := &networkMonitor{
+		network:   network,
+		alertChan: alertChan,
+		client:    client,
+		status:    status,
+		store:     store,
+		labels:    []string{network.Name, network.ChainId, network.Address},
+		window:    newSigningWindow(network.BackCheck),
+	}
+	m.restore()
+
+	var blocks chan rpc.Block
+	if network.Websocket {
+		blocks = make(chan rpc.Block)
+		go subscribeBlocks(network, client, blocks)
+	}
+
+	next := time.After(0)
+	for {
+		select {
+		case block := <-blocks:
+			m.recordBlock(block)
+		case <-next:
+			m.poll()
+			next = time.After(time.Duration(network.Interval) * time.Second)
+		}
+	}
+}
+
+// batchSize is the number of heights requested per JSON-RPC batch.
+func batchSize(network settings.Network) int {
+	if network.BatchSize == 0 {
+		return defaultBatchSize
+	}
+	return network.BatchSize
+}
+
+// nilVoteThreshold is the number of nil votes in the window that raises an
+// alert, defaulting to the missed blocks threshold.
+func nilVoteThreshold(network settings.Network) int {
+	if network.NilVoteThreshold == 0 {
+		return network.AlertThreshold
+	}
+	return network.NilVoteThreshold
+}
+
+// notify tags an alert with the network it came from, so it can be routed to
+// the network's own notifiers, and queues it.
+func notify(alertChan chan<- alert.Alert, network settings.Network, a alert.Alert) {
+	a.Network = network.Name
+	a.ChainId = network.ChainId
+	if network.Explorer != "" && a.Data.Height > 0 {
+		a.Data.Link = strings.ReplaceAll(network.Explorer, "{height}", strconv.FormatInt(a.Data.Height, 10))
+	}
+	alertChan <- a
+}
+
+// notify adds the active rpc to an alert before queueing it.
+func (m *networkMonitor) notify(a alert.Alert) {
+	if a.Data.Url == "" {
+		a.Data.Url = m.lastRpc
+	}
+	notify(m.alertChan, m.network, a)
+}
+
+// notifyWindow adds the window counts to a signing alert before queueing it.
+func (m *networkMonitor) notifyWindow(a alert.Alert, height int64, missing int, total int) {
+	a.Data.Height = height
+	a.Data.Missed = missing
+	a.Data.Window = total
+	m.notify(a)
+}
+
+func (m *networkMonitor) poll() {
+	network := m.network
+	m.status.Beat(network.Name)
+
+	// Find a working RPC with failover
+	activeRpc, err := getWorkingRpc(network.Rpcs, m.lastRpc, m.client)
+	if err != nil {
+		m.notify(alert.NoRpc(network.ChainId))
+		metrics.RpcUnavailable.Inc(m.labels...)
+		m.status.Update(network.Name, func(s *health.NetworkStatus) {
+			s.ActiveRpc = ""
+			s.RpcAlerted = true
+		})
+		return
+	}
+	if m.lastRpc != "" && activeRpc != m.lastRpc {
+		log.Println("RPC failover for", network.ChainId, "from", m.lastRpc, "to", activeRpc)
+		metrics.RpcFailovers.Inc(m.labels...)
+		m.noBatch = false
+	}
+	m.lastRpc = activeRpc
+	m.notify(alert.RpcRecovered(network.ChainId))
+
+	// Get the latest block for its time, to check for stalls, and height
+	block, err := rpc.GetLatestBlock(activeRpc, m.client)
+	if err != nil {
+		return
+	}
+	blockTime := block.Result.Block.Header.Time
+	height, heightErr := strconv.ParseInt(block.Result.Block.Header.Height, 10, 64)
+
+	metrics.BlockTimeLag.Set(time.Since(blockTime).Seconds(), m.labels...)
+	if network.StallTime > 0 {
+		if time.Since(blockTime) > time.Duration(network.StallTime)*time.Minute {
+			stalled := alert.Stalled(blockTime, network.ChainId)
+			stalled.Data.Height = height
+			m.notify(stalled)
+		} else {
+			m.notify(alert.StallCleared(network.ChainId))
+		}
+	}
+
+	if heightErr != nil {
+		m.notify(alert.InvalidHeight(network.ChainId))
+		return
+	}
+
+	// Only fetch commits in the backcheck window that haven't been checked yet.
+	// The newest canonical commit is the one for the height below the tip.
+	// With a websocket this only fills the heights it missed, such as those
+	// dropped while it reconnected.
+	unseen := m.window.unseen(height - 1)
+	commits := make(map[int64]rpc.Commit)
+	if len(unseen) > 1 && !m.noBatch {
+		commits, err = rpc.GetCommitsFromHeights(unseen, batchSize(network), activeRpc, m.client)
+		if err != nil {
+			m.noBatch = true
+			log.Println("Batch requests failed for", network.ChainId, "on", activeRpc, "- fetching heights individually:", err)
+		}
+	}
+	for _, h := range unseen {
+		commit, fetched := commits[h]
+		if !fetched {
+			commit, err = rpc.GetCommitFromHeight(strconv.FormatInt(h, 10), activeRpc, m.client)
+			if err != nil {
+				log.Println("Failed to fetch commit at height", h, ":", err)
+				continue
+			}
+			// A height the node pruned or hasn't reached yet has no
+			// signatures, and isn't a missed block.
+			if commit.Error != nil {
+				log.Println("Failed to fetch commit at height", h, ":", commit.Error)
+				continue
+			}
+		}
+		m.window.record(h, checkVote(network.Address, commit.Result.SignedHeader.Commit.Signatures))
+	}
+
+	m.evaluate(height, activeRpc)
+}
+
+// recordBlock adds the last commit of a block received over the websocket to
+// the window and re-evaluates it.
+func (m *networkMonitor) recordBlock(block rpc.Block) {
+	height, err := strconv.ParseInt(block.Result.Block.Header.Height, 10, 64)
+	if err != nil {
+		m.notify(alert.InvalidHeight(m.network.ChainId))
+		return
+	}
+	m.window.record(height-1, checkVote(m.network.Address, block.Result.Block.LastCommit.Signatures))
+
+	m.evaluate(height, m.lastRpc)
+}
+
+// evaluate counts the votes in the window of canonical commits below the
+// latest height and raises or clears the missed blocks and nil vote alerts.
+// The alert registry only sends the ones that change a condition.
+func (m *networkMonitor) evaluate(height int64, activeRpc string) {
+	network := m.network
+	missing, nilVotes, total := m.window.count(height - 1)
+	signed := total - missing - nilVotes
+	metrics.LatestHeight.Set(float64(height), m.labels...)
+	metrics.MissedBlocks.Set(float64(missing), m.labels...)
+	metrics.NilVotes.Set(float64(nilVotes), m.labels...)
+	metrics.WindowBlocks.Set(float64(total), m.labels...)
+
+	missedAlert := missing >= network.AlertThreshold
+	if missedAlert {
+		m.notifyWindow(alert.Missed(missing, total, network.Name), height-1, missing, total)
+	} else {
+		m.notifyWindow(alert.Cleared(signed, total, network.Name), height-1, missing, total)
+	}
+
+	// Nil votes mean the validator is online but prevoted nil, which points at
+	// a lagging node or a proposal problem rather than downtime.
+	nilVoteAlert := nilVotes >= nilVoteThreshold(network)
+	if nilVoteAlert {
+		m.notifyWindow(alert.NilVoted(nilVotes, total, network.Name), height-1, missing, total)
+	} else {
+		m.notifyWindow(alert.NilVotesCleared(nilVotes, total, network.Name), height-1, missing, total)
+	}
+
+	m.status.Update(network.Name, func(s *health.NetworkStatus) {
+		s.Height = height
+		s.ActiveRpc = activeRpc
+		s.Signed = signed
+		s.Missed = missing
+		s.NilVotes = nilVotes
+		s.Window = total
+		s.Alerted = missedAlert || nilVoteAlert
+		s.RpcAlerted = false
+		s.LastCheck = time.Now()
+	})
+	m.save()
+}
+
+type signerMetrics struct {
+	errorsCounter      int64
+	checkpointIndex    int64
+	checkpointUnixTime int64
+}
+
+// monitorSigner raises the signer condition while its metrics are down or its
+// checkpoints stall, and clears it otherwise. A checkpoint index that moved
+// on since the last check means the signer is signing again, whatever the
+// checkpoint time says.
+func monitorSigner(network settings.Network, alertChan chan<- alert.Alert, client *http.Client, store state.Store) {
+	saved := signerState{ErrorCount: -1, CheckpointIndex: -1}
+	labels := []string{network.Name, network.ChainId, network.Address}
+	key := "signer/" + network.Name
+	if _, err := store.Load(key, &saved); err != nil {
+		log.Println("Failed to restore signer state for", network.Name+":", err)
+	}
+
+	for {
+		sm, err := getSignerMetrics(network.SignerMetrics, client)
+		if err != nil {
+			notify(alertChan, network, alert.SignerDown(network.Name))
+			time.Sleep(time.Duration(network.Interval) * time.Second)
+			continue
+		}
+
+		recordSignerMetrics(sm, labels)
+
+		if saved.ErrorCount >= 0 && sm.errorsCounter > saved.ErrorCount {
+			notify(alertChan, network, alert.SignerError(network.Name, sm.errorsCounter))
+		}
+		progressed := saved.CheckpointIndex >= 0 && sm.checkpointIndex > saved.CheckpointIndex
+		if sm.errorsCounter != saved.ErrorCount || sm.checkpointIndex != saved.CheckpointIndex {
+			saved = signerState{ErrorCount: sm.errorsCounter, CheckpointIndex: sm.checkpointIndex}
+			if err = store.Save(key, saved); err != nil {
+				log.Println("Failed to save signer state for", network.Name+":", err)
+			}
+		}
+
+		lastCheckpoint := time.Unix(sm.checkpointUnixTime, 0)
+		if network.SignerStallMins > 0 && sm.checkpointUnixTime > 0 && time.Since(lastCheckpoint) > time.Duration(network.SignerStallMins)*time.Minute && !progressed {
+			notify(alertChan, network, alert.SignerStalled(lastCheckpoint, network.Name))
+		} else {
+			notify(alertChan, network, alert.SignerRecovered(network.Name))
+		}
+		time.Sleep(time.Duration(network.Interval) * time.Second)
+	}
+}
+
+func getSignerMetrics(url string, client *http.Client) (signerMetrics, error) {
+	req, err := http.NewRequestWithContext(context.Background(), "GET", url, nil)
+	if err != nil {
+		return signerMetrics{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return signerMetrics{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return signerMetrics{}, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return signerMetrics{}, err
+	}
+
+	var metrics = signerMetrics{checkpointIndex: -1, checkpointUnixTime: -1}
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "nomic_signer_errors "):
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				val, err := strconv.ParseFloat(fields[1], 64)
+				if err == nil {
+					metrics.errorsCounter = int64(val)
+				}
+			}
+		case strings.HasPrefix(line, "nomic_signer_checkpoint_index "):
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				val, err := strconv.ParseFloat(fields[1], 64)
+				if err == nil {
+					metrics.checkpointIndex = int64(val)
+				}
+			}
+		case strings.HasPrefix(line, "nomic_signer_checkpoint_timestamp "):
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				val, err := strconv.ParseFloat(fields[1], 64)
+				if err == nil {
+					metrics.checkpointUnixTime = int64(val)
+				}
+			}
+		}
+	}
+
+	return metrics, nil
+}
+
+// checkVote classifies the validator's entry in a commit. Absent validators
+// have an empty address, so a missing entry is also absent.
+func checkVote(address string, signatures []rpc.Signature) vote {
+	for _, sig := range signatures {
+		if sig.ValidatorAddress != address {
+			continue
+		}
+		switch sig.BlockIDFlag {
+		case rpc.BlockIDFlagAbsent:
+			return voteAbsent
+		case rpc.BlockIDFlagNil:
+			return voteNil
+		default:
+			// BlockIDFlagCommit, or a node old enough to omit the flag.
+			return voteCommit
+		}
+	}
+	return voteAbsent
+}
+
+func recordSignerMetrics(sm signerMetrics, labels []string) {
+	metrics.SignerErrors.Set(float64(sm.errorsCounter), labels...)
+	if sm.checkpointIndex >= 0 {
+		metrics.SignerCheckpoint.Set(float64(sm.checkpointIndex), labels...)
+	}
+	if sm.checkpointUnixTime > 0 {
+		metrics.SignerCheckpointAge.Set(time.Since(time.Unix(sm.checkpointUnixTime, 0)).Seconds(), labels...)
+	}
+}
@@ -0,0 +1,161 @@
+package scan
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/internal/health"
+	"github.com/cordtus/penpal/internal/rpc"
+	"github.com/cordtus/penpal/settings"
+	"github.com/cordtus/penpal/state"
+)
+
+func TestGetSignerMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`# HELP nomic_signer_errors Number of errors encountered
+# TYPE nomic_signer_errors counter
+nomic_signer_errors 7
+# HELP nomic_signer_checkpoint_index Current checkpoint index
+# TYPE nomic_signer_checkpoint_index gauge
+nomic_signer_checkpoint_index 42
+# HELP nomic_signer_checkpoint_timestamp The creation time of the newest checkpoint
+# TYPE nomic_signer_checkpoint_timestamp gauge
+nomic_signer_checkpoint_timestamp 1716400000
+`))
+	}))
+	defer srv.Close()
+
+	metrics, err := getSignerMetrics(srv.URL, srv.Client())
+	if err != nil {
+		t.Fatalf("getSignerMetrics returned error: %v", err)
+	}
+	if metrics.errorsCounter != 7 {
+		t.Fatalf("expected errorsCounter 7, got %d", metrics.errorsCounter)
+	}
+	if metrics.checkpointIndex != 42 {
+		t.Fatalf("expected checkpointIndex 42, got %d", metrics.checkpointIndex)
+	}
+	if metrics.checkpointUnixTime != 1716400000 {
+		t.Fatalf("expected checkpointUnixTime 1716400000, got %d", metrics.checkpointUnixTime)
+	}
+}
+
+func TestCheckVote(t *testing.T) {
+	signatures := []rpc.Signature{
+		{BlockIDFlag: rpc.BlockIDFlagAbsent},
+		{BlockIDFlag: rpc.BlockIDFlagCommit, ValidatorAddress: "COMMIT"},
+		{BlockIDFlag: rpc.BlockIDFlagNil, ValidatorAddress: "NIL"},
+		{ValidatorAddress: "LEGACY"},
+	}
+	cases := map[string]vote{
+		"COMMIT": voteCommit,
+		"NIL":    voteNil,
+		"LEGACY": voteCommit,
+		"ABSENT": voteAbsent,
+	}
+	for address, expected := range cases {
+		if v := checkVote(address, signatures); v != expected {
+			t.Fatalf("expected %d for %s, got %d", expected, address, v)
+		}
+	}
+}
+
+func TestSlashingLevel(t *testing.T) {
+	thresholds := []int{25, 50, 75, 90}
+	cases := map[int64]int{0: 0, 124: 0, 125: 25, 374: 50, 375: 75, 499: 90, 500: 90}
+	for missed, expected := range cases {
+		if level := slashingLevel(missed, 500, thresholds); level != expected {
+			t.Fatalf("expected level %d for %d missed, got %d", expected, missed, level)
+		}
+	}
+}
+
+func TestPollFillsWebsocketGaps(t *testing.T) {
+	var commits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/commit" {
+			commits = append(commits, r.URL.Query().Get("height"))
+			_, _ = w.Write([]byte(`{"result": {"signed_header": {"commit": {"signatures": [{"validator_address": "VAL", "block_id_flag": 1}]}}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result": {"block": {"header": {"chain_id": "chain-1", "height": "11", "time": "` + time.Now().Add(-time.Hour).UTC().Format(time.RFC3339) + `"}}}}`))
+	}))
+	defer srv.Close()
+
+	network := settings.Network{Name: "mainnet", ChainId: "chain-1", Address: "VAL", Rpcs: []string{srv.URL}, Websocket: true, BackCheck: 4, AlertThreshold: 5, StallTime: 30}
+	alerts := make(chan alert.Alert, 100)
+	status := health.NewRegistry()
+	status.Register(network.Name, network.ChainId, network.Address, time.Minute)
+	store, err := state.Open(filepath.Join(t.TempDir(), "penpal.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+	m := &networkMonitor{network: network, alertChan: alerts, client: srv.Client(), status: status, store: store, window: newSigningWindow(network.BackCheck)}
+	// Blocks arrived over the websocket, except one dropped while it
+	// reconnected.
+	m.window.record(7, voteCommit)
+	m.window.record(9, voteCommit)
+	m.window.record(10, voteCommit)
+	m.poll()
+
+	if len(commits) != 1 || commits[0] != "8" || !m.window.has(8) {
+		t.Fatalf("expected the dropped height to be fetched, fetched %v", commits)
+	}
+	if missing, _, total := m.window.count(10); missing != 1 || total != 4 {
+		t.Fatalf("expected 1 missed of 4, got %d of %d", missing, total)
+	}
+	close(alerts)
+	var stalled bool
+	for a := range alerts {
+		if a.Data.Url != srv.URL {
+			t.Fatalf("expected %s alert to carry the active rpc, got %q", a.Kind, a.Data.Url)
+		}
+		if a.Kind == alert.KindStall {
+			stalled = a.AlertType == alert.Stall && a.Data.Height == 11
+		}
+	}
+	if !stalled {
+		t.Fatalf("expected a stall alert with the latest height")
+	}
+}
+
+func TestPollSkipsCommitErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/commit" {
+			_, _ = w.Write([]byte(`{"jsonrpc": "2.0", "id": -1, "error": {"code": -32603, "message": "Internal error", "data": "height 8 is not available, lowest height is 9"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result": {"block": {"header": {"chain_id": "chain-1", "height": "11", "time": "` + time.Now().UTC().Format(time.RFC3339) + `"}}}}`))
+	}))
+	defer srv.Close()
+
+	network := settings.Network{Name: "mainnet", ChainId: "chain-1", Address: "VAL", Rpcs: []string{srv.URL}, BackCheck: 4, AlertThreshold: 1, StallTime: 30}
+	alerts := make(chan alert.Alert, 100)
+	status := health.NewRegistry()
+	status.Register(network.Name, network.ChainId, network.Address, time.Minute)
+	store, err := state.Open(filepath.Join(t.TempDir(), "penpal.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+	m := &networkMonitor{network: network, alertChan: alerts, client: srv.Client(), status: status, store: store, window: newSigningWindow(network.BackCheck), noBatch: true}
+	m.poll()
+
+	for _, h := range []int64{7, 8, 9, 10} {
+		if m.window.has(h) {
+			t.Fatalf("expected height %d not to be recorded from an error response", h)
+		}
+	}
+	close(alerts)
+	for a := range alerts {
+		if a.AlertType == alert.Miss {
+			t.Fatalf("expected no missed blocks alert, got %q", a.Message)
+		}
+	}
+}
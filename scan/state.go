@@ -0,0 +1,86 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/cordtus/penpal/alert"
+	"github.com/cordtus/penpal/state"
+)
+
+// alertsSaveInterval is how often the alert registry is saved when it
+// changed.
+const alertsSaveInterval = 5 * time.Second
+
+// networkState is what a network monitor restores after a restart.
+type networkState struct {
+	Window  []windowResult `json:"window"`
+	LastRpc string         `json:"last_rpc"`
+}
+
+// signerState is the signer metrics baseline, so errors counted while penpal
+// was down are still reported and a stalled signer isn't cleared by a
+// checkpoint it already reported before the restart.
+type signerState struct {
+	ErrorCount      int64 `json:"error_count"`
+	CheckpointIndex int64 `json:"checkpoint_index"`
+}
+
+// restoreAlerts loads the alert registry saved by a previous run.
+func restoreAlerts(store state.Store, registry *alert.Registry) {
+	var snapshot alert.Snapshot
+	found, err := store.Load("alerts", &snapshot)
+	if err != nil {
+		log.Println("Failed to restore alert state:", err)
+		return
+	}
+	if found {
+		registry.Restore(snapshot)
+		log.Println("Restored", len(snapshot.Conditions), "alert conditions and", len(snapshot.Silences), "silences")
+	}
+}
+
+// saveAlerts saves the alert registry whenever it changed.
+func saveAlerts(store state.Store, registry *alert.Registry) {
+	var last []byte
+	for {
+		time.Sleep(alertsSaveInterval)
+		snapshot := registry.Snapshot()
+		data, err := json.Marshal(snapshot)
+		if err != nil || bytes.Equal(data, last) {
+			continue
+		}
+		if err = store.Save("alerts", snapshot); err != nil {
+			log.Println("Failed to save alert state:", err)
+			continue
+		}
+		last = data
+	}
+}
+
+// restore loads the signing window and active rpc a previous run saved.
+func (m *networkMonitor) restore() {
+	var saved networkState
+	found, err := m.store.Load("network/"+m.network.Name, &saved)
+	if err != nil {
+		log.Println("Failed to restore state for", m.network.Name+":", err)
+		return
+	}
+	if !found {
+		return
+	}
+	for _, r := range saved.Window {
+		m.window.record(r.Height, r.Vote)
+	}
+	m.lastRpc = saved.LastRpc
+	log.Println("Restored", len(saved.Window), "signing results for", m.network.Name)
+}
+
+func (m *networkMonitor) save() {
+	saved := networkState{Window: m.window.results(), LastRpc: m.lastRpc}
+	if err := m.store.Save("network/"+m.network.Name, saved); err != nil {
+		log.Println("Failed to save state for", m.network.Name+":", err)
+	}
+}
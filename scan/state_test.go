@@ -0,0 +1,50 @@
+package scan
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/cordtus/penpal/settings"
+	"github.com/cordtus/penpal/state"
+)
+
+func TestNetworkStateRestore(t *testing.T) {
+	store, err := state.Open(filepath.Join(t.TempDir(), "penpal.db"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+	network := settings.Network{Name: "mainnet", BackCheck: 4}
+	m := &networkMonitor{network: network, store: store, window: newSigningWindow(network.BackCheck)}
+	m.window.record(10, voteCommit)
+	m.window.record(11, voteAbsent)
+	m.window.record(12, voteNil)
+	m.lastRpc = "http://rpc-2"
+	m.save()
+
+	restarted := &networkMonitor{network: network, store: store, window: newSigningWindow(network.BackCheck)}
+	restarted.restore()
+	missing, nilVotes, total := restarted.window.count(12)
+	if missing != 1 || nilVotes != 1 || total != 3 || restarted.lastRpc != "http://rpc-2" {
+		t.Fatalf("unexpected restored state: %d missing, %d nil, %d total, rpc %s", missing, nilVotes, total, restarted.lastRpc)
+	}
+	if unseen := restarted.window.unseen(13); len(unseen) != 1 || unseen[0] != 13 {
+		t.Fatalf("expected only the new height to be fetched, got %v", unseen)
+	}
+}
+
+func TestGetWorkingRpcPrefersLast(t *testing.T) {
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = w.Write([]byte(`{"result": {"block": {"header": {"chain_id": "chain-1", "height": "10"}}}}`))
+	}))
+	defer srv.Close()
+
+	url, err := getWorkingRpc([]string{srv.URL + "/rpc-1", srv.URL + "/rpc-2"}, srv.URL+"/rpc-2", srv.Client())
+	if err != nil || url != srv.URL+"/rpc-2" || len(requested) != 1 {
+		t.Fatalf("expected the last working rpc to be tried first, got %s %v after %v", url, err, requested)
+	}
+}
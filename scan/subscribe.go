@@ -0,0 +1,39 @@
+package scan
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cordtus/penpal/internal/rpc"
+	"github.com/cordtus/penpal/settings"
+)
+
+const (
+	minWsBackoff = 1 * time.Second
+	maxWsBackoff = 2 * time.Minute
+)
+
+// subscribeBlocks keeps a NewBlock subscription open against the first working
+// rpc, reconnecting with exponential backoff whenever the socket drops.
+func subscribeBlocks(network settings.Network, client *http.Client, blocks chan<- rpc.Block) {
+	backoff := minWsBackoff
+	for {
+		url, err := getWorkingRpc(network.Rpcs, "", client)
+		if err == nil {
+			connected := time.Now()
+			err = rpc.SubscribeNewBlocks(context.Background(), url, blocks)
+			// Only a connection that stayed up resets the backoff.
+			if time.Since(connected) > maxWsBackoff {
+				backoff = minWsBackoff
+			}
+		}
+		log.Println("Websocket subscription for", network.ChainId, "dropped:", err, "- retrying in", backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxWsBackoff {
+			backoff = maxWsBackoff
+		}
+	}
+}
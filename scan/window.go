@@ -0,0 +1,91 @@
+package scan
+
+// vote is how a validator took part in the commit for a height.
+type vote int
+
+const (
+	voteAbsent vote = iota
+	voteCommit
+	voteNil
+)
+
+// signingWindow is a fixed-size ring buffer of per-height votes covering the
+// back-check window. Each height maps to slot height%size, so a newer height
+// overwrites the result that has fallen out of the window.
+type signingWindow struct {
+	slots []windowSlot
+}
+
+type windowSlot struct {
+	height int64
+	vote   vote
+}
+
+// windowResult is a recorded height as it is saved in the state store.
+type windowResult struct {
+	Height int64 `json:"height"`
+	Vote   vote  `json:"vote"`
+}
+
+func newSigningWindow(size int) *signingWindow {
+	return &signingWindow{slots: make([]windowSlot, size)}
+}
+
+func (w *signingWindow) slot(height int64) *windowSlot {
+	return &w.slots[height%int64(len(w.slots))]
+}
+
+// record stores the vote for height unless the slot already holds a newer height.
+func (w *signingWindow) record(height int64, v vote) {
+	s := w.slot(height)
+	if s.height > height {
+		return
+	}
+	s.height = height
+	s.vote = v
+}
+
+func (w *signingWindow) has(height int64) bool {
+	return height > 0 && w.slot(height).height == height
+}
+
+// unseen returns the heights in the window ending at latest that have no
+// result yet, newest first. After an rpc outage these are the gaps to fill.
+func (w *signingWindow) unseen(latest int64) []int64 {
+	var heights []int64
+	for h := latest; h > latest-int64(len(w.slots)) && h > 0; h-- {
+		if !w.has(h) {
+			heights = append(heights, h)
+		}
+	}
+	return heights
+}
+
+// count returns the absent votes, nil votes and checked heights in the
+// window ending at latest.
+func (w *signingWindow) count(latest int64) (missing, nilVotes, total int) {
+	for _, s := range w.slots {
+		if s.height == 0 || s.height > latest || s.height <= latest-int64(len(w.slots)) {
+			continue
+		}
+		switch s.vote {
+		case voteAbsent:
+			missing++
+		case voteNil:
+			nilVotes++
+		}
+		total++
+	}
+	return
+}
+
+// results returns the heights in the window and their votes, for saving.
+func (w *signingWindow) results() []windowResult {
+	var results []windowResult
+	for _, s := range w.slots {
+		if s.height > 0 {
+			results = append(results, windowResult{Height: s.height, Vote: s.vote})
+		}
+	}
+	return results
+}
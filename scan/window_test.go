@@ -0,0 +1,33 @@
+package scan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSigningWindow(t *testing.T) {
+	w := newSigningWindow(5)
+	for h := int64(1); h <= 5; h++ {
+		w.record(h, voteCommit)
+	}
+	w.record(3, voteAbsent)
+	w.record(4, voteNil)
+	if missing, nilVotes, total := w.count(5); missing != 1 || nilVotes != 1 || total != 5 {
+		t.Fatalf("expected 1 absent and 1 nil of 5, got %d absent and %d nil of %d", missing, nilVotes, total)
+	}
+
+	// Height 8 arrives after an outage; 6 and 7 are gaps, 3 has left the window.
+	w.record(8, voteCommit)
+	if unseen := w.unseen(8); !reflect.DeepEqual(unseen, []int64{7, 6}) {
+		t.Fatalf("expected unseen [7 6], got %v", unseen)
+	}
+	if missing, nilVotes, total := w.count(8); missing != 0 || nilVotes != 1 || total != 3 {
+		t.Fatalf("expected 0 absent and 1 nil of 3, got %d absent and %d nil of %d", missing, nilVotes, total)
+	}
+
+	// A late result for an old height must not overwrite a newer one.
+	w.record(3, voteAbsent)
+	if !w.has(8) {
+		t.Fatal("height 8 was overwritten by height 3")
+	}
+}
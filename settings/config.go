@@ -0,0 +1,113 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+func New(file string) (err error) {
+	_, err = os.Stat(file)
+	if err == nil {
+		err = errors.New("config file already exists at " + file)
+		return
+	} else if !os.IsNotExist(err) {
+		return
+	}
+	configFile, err := os.Create(filepath.Clean(file))
+	if err != nil {
+		return
+	}
+	encoder := json.NewEncoder(configFile)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(Config{
+		Networks: []Network{
+			{
+				Name:               "Network1",
+				ChainId:            "network-1",
+				Address:            "VALIDATOR_HEX_ADDRESS",
+				Rpcs:               []string{"http://localhost:26657"},
+				Api:                "",
+				RpcAlert:           true,
+				Websocket:          false,
+				SignerMetrics:      "",
+				SignerStallMins:    60,
+				BackCheck:          20,
+				BatchSize:          10,
+				AlertThreshold:     5,
+				NilVoteThreshold:   0,
+				Interval:           15,
+				SlashingThresholds: []int{},
+				StallTime:          30,
+				Explorer:           "",
+			},
+		},
+		Notifiers: Notifiers{
+			Sinks: Sinks{
+				Telegram: Telegram{
+					Key:       "api_key",
+					Chat:      "chat_id",
+					ParseMode: "HTML",
+					Commands:  false,
+					ApiUrl:    "",
+				},
+				Discord: Discord{
+					Webhook: "",
+					Embeds:  true,
+				},
+				PagerDuty: PagerDuty{
+					RoutingKey: "",
+					Url:        "",
+				},
+				Alertmanager: Alertmanager{
+					Url: "",
+				},
+				Opsgenie: Opsgenie{
+					Key: "",
+					Url: "",
+				},
+				Slack: Slack{
+					Webhook: "",
+				},
+				Matrix: Matrix{
+					Homeserver: "",
+					Token:      "",
+					Room:       "",
+				},
+				Mattermost: Mattermost{
+					Webhook: "",
+					Channel: "",
+				},
+				Email: Email{
+					Host:       "",
+					Port:       587,
+					Username:   "",
+					Password:   "",
+					From:       "",
+					To:         []string{},
+					TLS:        "starttls",
+					DigestMins: 0,
+				},
+			},
+			Routes:      []Route{},
+			Named:       map[string]Sinks{},
+			Templates:   map[string]string{},
+			Escalations: []Escalation{},
+		},
+		Silences: []Silence{},
+		Health: Health{
+			Name:            "",
+			Interval:        1,
+			Port:            "8080",
+			Nodes:           []string{},
+			MissedIntervals: 3,
+			Token:           "",
+		},
+		State: "./penpal.db",
+	})
+	if err == nil {
+		err = errors.New("generated a new config at " + file)
+	}
+	return
+}
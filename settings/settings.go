@@ -0,0 +1,316 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func Load(file string) (config Config, err error) {
+	configFile := filepath.Clean(file)
+	if _, err = os.Stat(configFile); os.IsNotExist(err) {
+		err = errors.New("no config found - run with `-c` `/path/to/config`, or `-init` to generate one")
+		return
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return
+	}
+	warn := config.validate()
+	if warn != "" {
+		err = errors.New("check config - " + warn)
+		return
+	}
+	return
+}
+
+func (c Config) validate() string {
+	if len(c.Networks) == 0 {
+		return "no networks found - check config"
+	}
+
+	for _, network := range c.Networks {
+		if network.ChainId == "" {
+			return "chain-id value invalid - check config"
+		}
+		if network.Address == "" {
+			return "address value invalid for " + network.Name + " - check config"
+		}
+		if network.BackCheck <= 0 {
+			return "backcheck value invalid - check config"
+		}
+		if network.BatchSize < 0 {
+			return "batch size value invalid - check config"
+		}
+		if network.AlertThreshold <= 0 || network.AlertThreshold > network.BackCheck {
+			return "alert threshold value invalid - check config"
+		}
+		if network.NilVoteThreshold < 0 || network.NilVoteThreshold > network.BackCheck {
+			return "nil vote threshold value invalid - check config"
+		}
+		if network.Interval <= 0 {
+			return "check interval value invalid - check config"
+		}
+		if network.StallTime < 0 {
+			return "stall time value invalid - check config"
+		}
+		if network.SignerStallMins < 0 {
+			return "signer stall time value invalid - check config"
+		}
+
+		for _, rpcURL := range network.Rpcs {
+			parsedURL, err := url.Parse(rpcURL)
+			if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") {
+				return "rpc \"" + rpcURL + "\" invalid for the network"
+			}
+		}
+		if network.Api != "" {
+			parsedURL, err := url.Parse(network.Api)
+			if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") {
+				return "api \"" + network.Api + "\" invalid for the network"
+			}
+		}
+		if len(network.SlashingThresholds) > 0 && network.Api == "" {
+			return "slashing thresholds for " + network.Name + " need an api - check config"
+		}
+		for _, threshold := range network.SlashingThresholds {
+			if threshold <= 0 || threshold > 100 {
+				return "slashing threshold value invalid - check config"
+			}
+		}
+		if network.Explorer != "" && !validUrl(strings.ReplaceAll(network.Explorer, "{height}", "1")) {
+			return "explorer \"" + network.Explorer + "\" invalid for " + network.Name + " - check config"
+		}
+		if network.SignerMetrics != "" {
+			parsedURL, err := url.Parse(network.SignerMetrics)
+			if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") {
+				return "signer metrics \"" + network.SignerMetrics + "\" invalid for the network"
+			}
+		}
+	}
+
+	if warn := c.Notifiers.Sinks.validate(); warn != "" {
+		return warn
+	}
+	if warn := validateRoutes(c.Notifiers.Routes); warn != "" {
+		return warn
+	}
+	for name, sinks := range c.Notifiers.Named {
+		if sinks.Empty() {
+			return "named notifier " + name + " has no notifiers - check config"
+		}
+		if warn := sinks.validate(); warn != "" {
+			return warn
+		}
+	}
+	for _, escalation := range c.Notifiers.Escalations {
+		if warn := escalation.validate(c); warn != "" {
+			return warn
+		}
+	}
+	hasSink := !c.Notifiers.Sinks.Empty() || len(c.Notifiers.Routes) > 0
+	for _, network := range c.Networks {
+		own := network.Notifiers
+		if warn := own.Sinks.validate(); warn != "" {
+			return warn
+		}
+		if warn := validateRoutes(own.Routes); warn != "" {
+			return warn
+		}
+		for _, name := range own.Use {
+			if _, exists := c.Notifiers.Named[name]; !exists {
+				return "named notifier " + name + " for " + network.Name + " not found - check config"
+			}
+		}
+		ownSink := !own.Sinks.Empty() || len(own.Routes) > 0 || len(own.Use) > 0
+		if own.Override && !ownSink {
+			return "notifier override for " + network.Name + " has no notifiers - check config"
+		}
+		hasSink = hasSink || ownSink
+	}
+	if !hasSink {
+		return "no notifiers configured - check config"
+	}
+
+	for _, silence := range c.Silences {
+		for _, name := range silence.Networks {
+			if !c.HasNetwork(name) {
+				return "silence network " + name + " not found - check config"
+			}
+		}
+	}
+
+	if len(c.Health.Nodes) > 0 {
+		if c.Health.Interval <= 0 {
+			return "health interval value invalid - check config"
+		}
+		if c.Health.Port == "" {
+			return "health port required for peer heartbeat - check config"
+		}
+	}
+	if c.Health.MissedIntervals < 0 {
+		return "health missed intervals value invalid - check config"
+	}
+	for _, node := range c.Health.Nodes {
+		parsedURL, err := url.Parse(node)
+		if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") {
+			return "health node \"" + node + "\" invalid - check config"
+		}
+	}
+
+	return ""
+}
+
+func (e Escalation) validate(c Config) string {
+	if e.RepeatMins < 0 || e.MaxRepeats < 0 || e.EscalateMins < 0 {
+		return "escalation value invalid - check config"
+	}
+	if e.RepeatMins == 0 && e.EscalateMins == 0 {
+		return "escalation needs repeat_mins or escalate_mins - check config"
+	}
+	if e.EscalateMins > 0 && e.Escalate.Empty() {
+		return "escalation has no notifiers to escalate to - check config"
+	}
+	for _, name := range e.Networks {
+		if !c.HasNetwork(name) {
+			return "escalation network " + name + " not found - check config"
+		}
+	}
+	for _, severity := range e.Severities {
+		if !contains(Severities, severity) {
+			return "escalation severity \"" + severity + "\" invalid - check config"
+		}
+	}
+	return e.Escalate.validate()
+}
+
+func validateRoutes(routes []Route) string {
+	for _, route := range routes {
+		for _, severity := range route.Severities {
+			if !contains(Severities, severity) {
+				return "route severity \"" + severity + "\" invalid - check config"
+			}
+		}
+		if route.Sinks.Empty() {
+			return "route has no notifiers - check config"
+		}
+		if warn := route.Sinks.validate(); warn != "" {
+			return warn
+		}
+	}
+	return ""
+}
+
+func (s Sinks) validate() string {
+	if s.Telegram.Key != "" && s.Telegram.Chat == "" {
+		return "telegram chat id missing - check config"
+	}
+	if s.Telegram.ApiUrl != "" && !validUrl(s.Telegram.ApiUrl) {
+		return "telegram api url \"" + s.Telegram.ApiUrl + "\" invalid - check config"
+	}
+	if s.Telegram.ParseMode != "" && s.Telegram.ParseMode != "HTML" && s.Telegram.ParseMode != "MarkdownV2" {
+		return "telegram parse mode \"" + s.Telegram.ParseMode + "\" invalid - check config"
+	}
+	if s.PagerDuty.Url != "" && !validUrl(s.PagerDuty.Url) {
+		return "pagerduty url \"" + s.PagerDuty.Url + "\" invalid - check config"
+	}
+	if s.Alertmanager.Url != "" && !validUrl(s.Alertmanager.Url) {
+		return "alertmanager url \"" + s.Alertmanager.Url + "\" invalid - check config"
+	}
+	if s.Opsgenie.Url != "" && !validUrl(s.Opsgenie.Url) {
+		return "opsgenie url \"" + s.Opsgenie.Url + "\" invalid - check config"
+	}
+	if s.Slack.Webhook != "" && !validUrl(s.Slack.Webhook) {
+		return "slack webhook \"" + s.Slack.Webhook + "\" invalid - check config"
+	}
+	if s.Matrix.Homeserver != "" || s.Matrix.Token != "" || s.Matrix.Room != "" {
+		if !validUrl(s.Matrix.Homeserver) {
+			return "matrix homeserver \"" + s.Matrix.Homeserver + "\" invalid - check config"
+		}
+		if s.Matrix.Token == "" {
+			return "matrix access token missing - check config"
+		}
+		if s.Matrix.Room == "" {
+			return "matrix room id missing - check config"
+		}
+	}
+	if s.Mattermost.Webhook != "" && !validUrl(s.Mattermost.Webhook) {
+		return "mattermost webhook \"" + s.Mattermost.Webhook + "\" invalid - check config"
+	}
+	if s.Email.Host != "" {
+		if s.Email.Port <= 0 || s.Email.Port > 65535 {
+			return "email port value invalid - check config"
+		}
+		if s.Email.From == "" || len(s.Email.To) == 0 {
+			return "email from and to addresses required - check config"
+		}
+		if s.Email.TLS != "" && s.Email.TLS != "starttls" && s.Email.TLS != "implicit" && s.Email.TLS != "none" {
+			return "email tls \"" + s.Email.TLS + "\" invalid - check config"
+		}
+		if s.Email.DigestMins < 0 {
+			return "email digest value invalid - check config"
+		}
+	}
+	return ""
+}
+
+// HasNetwork reports whether a network of that name is configured.
+func (c Config) HasNetwork(name string) bool {
+	for _, network := range c.Networks {
+		if network.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AllSinks returns every block of sinks in the config: the global ones,
+// routes, named notifiers, escalation tiers and each network's own.
+func (c Config) AllSinks() []Sinks {
+	all := []Sinks{c.Notifiers.Sinks}
+	for _, route := range c.Notifiers.Routes {
+		all = append(all, route.Sinks)
+	}
+	for _, sinks := range c.Notifiers.Named {
+		all = append(all, sinks)
+	}
+	for _, escalation := range c.Notifiers.Escalations {
+		all = append(all, escalation.Escalate)
+	}
+	for _, network := range c.Networks {
+		all = append(all, network.Notifiers.Sinks)
+		for _, route := range network.Notifiers.Routes {
+			all = append(all, route.Sinks)
+		}
+	}
+	return all
+}
+
+// Empty reports whether no sink is configured.
+func (s Sinks) Empty() bool {
+	return s.Telegram.Key == "" && s.Discord.Webhook == "" && s.PagerDuty.RoutingKey == "" &&
+		s.Alertmanager.Url == "" && s.Opsgenie.Key == "" &&
+		s.Slack.Webhook == "" && s.Matrix.Homeserver == "" && s.Mattermost.Webhook == "" &&
+		s.Email.Host == ""
+}
+
+func validUrl(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	return err == nil && parsedURL.Scheme != "" && parsedURL.Host != "" && (parsedURL.Scheme == "https" || parsedURL.Scheme == "http")
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package settings
+
+type (
+	// Config is the whole config file. State is the database file penpal
+	// keeps its state in across restarts, penpal.db in the working directory
+	// when it is empty.
+	Config struct {
+		Networks  []Network `json:"networks"`
+		Notifiers Notifiers `json:"notifiers"`
+		Silences  []Silence `json:"silences"`
+		Health    Health    `json:"health"`
+		State     string    `json:"state"`
+	}
+
+	Network struct {
+		Name               string           `json:"name"`
+		ChainId            string           `json:"chain_id"`
+		Address            string           `json:"address"`
+		Rpcs               []string         `json:"rpcs"`
+		Api                string           `json:"api"`
+		RpcAlert           bool             `json:"rpc_alert"`
+		Websocket          bool             `json:"websocket"`
+		SignerMetrics      string           `json:"signer_metrics"`
+		SignerStallMins    int              `json:"signer_stall_mins"`
+		BackCheck          int              `json:"back_check"`
+		BatchSize          int              `json:"batch_size"`
+		AlertThreshold     int              `json:"alert_threshold"`
+		NilVoteThreshold   int              `json:"nil_vote_threshold"`
+		Interval           int              `json:"interval"`
+		SlashingThresholds []int            `json:"slashing_thresholds"`
+		StallTime          int              `json:"stall_time"`
+		Explorer           string           `json:"explorer"`
+		Notifiers          NetworkNotifiers `json:"notifiers"`
+	}
+
+	// Health configures the health server. Token is required as a bearer
+	// token to change silences through it, without one only local requests
+	// can.
+	Health struct {
+		Name            string   `json:"name"`
+		Interval        int      `json:"interval"`
+		Port            string   `json:"port"`
+		Nodes           []string `json:"nodes"`
+		MissedIntervals int      `json:"missed_intervals"`
+		Token           string   `json:"token"`
+	}
+
+	// Silence drops alerts of Types from Networks between Start and End, or
+	// for Duration from Start. Empty lists match everything and an empty
+	// Start means now. Times are RFC3339 and durations like "90m". Recurring
+	// is "daily" or "weekly" to repeat the window, and with Downgrade set
+	// matching alerts are sent as info instead of dropped.
+	Silence struct {
+		Networks  []string `json:"networks"`
+		Types     []string `json:"types"`
+		Start     string   `json:"start"`
+		End       string   `json:"end"`
+		Duration  string   `json:"duration"`
+		Recurring string   `json:"recurring"`
+		Reason    string   `json:"reason"`
+		Downgrade bool     `json:"downgrade"`
+	}
+
+	// Notifiers are the default sinks plus routes that send alerts of some
+	// severities or types to other sinks instead. Templates override alert
+	// messages by template name, and each sink can override them again.
+	// Alerts a sink never accepted are appended to the DeadLetter file, and
+	// alerts not delivered yet are kept in the Outbox file across restarts.
+	Notifiers struct {
+		Sinks
+		Routes      []Route           `json:"routes"`
+		Named       map[string]Sinks  `json:"named"`
+		Templates   map[string]string `json:"templates"`
+		Escalations []Escalation      `json:"escalations"`
+		DeadLetter  string            `json:"dead_letter"`
+		Outbox      string            `json:"outbox"`
+	}
+
+	// Escalation repeats unacknowledged alerts matching Networks, Severities
+	// and Types every RepeatMins minutes, at most MaxRepeats times, and after
+	// EscalateMins minutes also sends them to the Escalate sinks. Empty lists
+	// match everything, and the first matching escalation applies.
+	Escalation struct {
+		Networks     []string `json:"networks"`
+		Severities   []string `json:"severities"`
+		Types        []string `json:"types"`
+		RepeatMins   int      `json:"repeat_mins"`
+		MaxRepeats   int      `json:"max_repeats"`
+		EscalateMins int      `json:"escalate_mins"`
+		Escalate     Sinks    `json:"escalate"`
+	}
+
+	// NetworkNotifiers add sinks for a single network's alerts, either its own
+	// or ones from Notifiers.Named. With Override set the global notifiers
+	// are not used for the network at all.
+	NetworkNotifiers struct {
+		Sinks
+		Routes   []Route  `json:"routes"`
+		Use      []string `json:"use"`
+		Override bool     `json:"override"`
+	}
+
+	// Route sends alerts matching any of Severities and any of Types to its
+	// sinks. An empty list matches everything.
+	Route struct {
+		Severities []string `json:"severities"`
+		Types      []string `json:"types"`
+		Sinks
+	}
+
+	Sinks struct {
+		Telegram     Telegram     `json:"telegram"`
+		Discord      Discord      `json:"discord"`
+		PagerDuty    PagerDuty    `json:"pagerduty"`
+		Alertmanager Alertmanager `json:"alertmanager"`
+		Opsgenie     Opsgenie     `json:"opsgenie"`
+		Slack        Slack        `json:"slack"`
+		Matrix       Matrix       `json:"matrix"`
+		Mattermost   Mattermost   `json:"mattermost"`
+		Email        Email        `json:"email"`
+	}
+
+	// Telegram sends plain text unless ParseMode is "HTML" or "MarkdownV2".
+	// With Commands set the bot also answers commands from Chat. ApiUrl
+	// defaults to https://api.telegram.org.
+	Telegram struct {
+		Key       string            `json:"key"`
+		Chat      string            `json:"chat_id"`
+		ParseMode string            `json:"parse_mode"`
+		Commands  bool              `json:"commands"`
+		ApiUrl    string            `json:"api_url"`
+		Templates map[string]string `json:"templates,omitempty"`
+	}
+
+	// Discord sends plain content, or an embed with the alert's details when
+	// Embeds is set.
+	Discord struct {
+		Webhook   string            `json:"webhook"`
+		Embeds    bool              `json:"embeds"`
+		Templates map[string]string `json:"templates,omitempty"`
+	}
+
+	// PagerDuty sends Events API v2 events. Url defaults to the public
+	// events endpoint.
+	PagerDuty struct {
+		RoutingKey string            `json:"routing_key"`
+		Url        string            `json:"url"`
+		Templates  map[string]string `json:"templates,omitempty"`
+	}
+
+	// Alertmanager posts to <url>/api/v2/alerts of a Prometheus Alertmanager
+	// or anything accepting the same format.
+	Alertmanager struct {
+		Url       string            `json:"url"`
+		Templates map[string]string `json:"templates,omitempty"`
+	}
+
+	// Opsgenie creates and closes alerts through the Alert API. Url defaults
+	// to https://api.opsgenie.com, use https://api.eu.opsgenie.com for EU accounts.
+	Opsgenie struct {
+		Key       string            `json:"api_key"`
+		Url       string            `json:"url"`
+		Templates map[string]string `json:"templates,omitempty"`
+	}
+
+	Slack struct {
+		Webhook   string            `json:"webhook"`
+		Templates map[string]string `json:"templates,omitempty"`
+	}
+
+	Matrix struct {
+		Homeserver string            `json:"homeserver"`
+		Token      string            `json:"access_token"`
+		Room       string            `json:"room_id"`
+		Templates  map[string]string `json:"templates,omitempty"`
+	}
+
+	// Mattermost posts to an incoming webhook. Channel overrides the
+	// webhook's default channel when set.
+	Mattermost struct {
+		Webhook   string            `json:"webhook"`
+		Channel   string            `json:"channel"`
+		Templates map[string]string `json:"templates,omitempty"`
+	}
+
+	// Email sends alerts over SMTP. TLS is "starttls" (default), "implicit"
+	// or "none". With DigestMins set, non-critical alerts are batched into
+	// one email every DigestMins minutes.
+	Email struct {
+		Host       string            `json:"host"`
+		Port       int               `json:"port"`
+		Username   string            `json:"username"`
+		Password   string            `json:"password"`
+		From       string            `json:"from"`
+		To         []string          `json:"to"`
+		TLS        string            `json:"tls"`
+		DigestMins int               `json:"digest_mins"`
+		Templates  map[string]string `json:"templates,omitempty"`
+	}
+)
+
+// Severities are the alert severities routes can match on.
+var Severities = []string{"info", "warning", "critical"}
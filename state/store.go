@@ -0,0 +1,70 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultPath is where penpal keeps its state when the config doesn't say.
+const DefaultPath = "penpal.db"
+
+var bucket = []byte("state")
+
+// Open returns a BoltStore in the database file at path, creating it if
+// needed.
+func Open(path string) (*BoltStore, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+	// Only one process can hold the database, so a second penpal sharing it
+	// fails instead of waiting forever.
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Load(key string, v interface{}) (bool, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// The value is only valid during the transaction.
+		data = append([]byte(nil), tx.Bucket(bucket).Get([]byte(key))...)
+		return nil
+	})
+	if err != nil || len(data) == 0 {
+		return false, err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return false, errors.New("state " + key + " is corrupt: " + err.Error())
+	}
+	return true, nil
+}
+
+// Save writes the value in a transaction, so a crash never leaves a half
+// written state behind.
+func (s *BoltStore) Save(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+}
+
+// Close releases the database so another process can open it.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
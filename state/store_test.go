@@ -0,0 +1,42 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "penpal.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	type saved struct {
+		Height int64 `json:"height"`
+	}
+	var s saved
+	if found, err := store.Load("network/mainnet", &s); found || err != nil {
+		t.Fatalf("expected nothing saved yet, got %v %v", found, err)
+	}
+	if err = store.Save("network/mainnet", saved{Height: 42}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if found, err := store.Load("network/testnet", &s); found || err != nil {
+		t.Fatalf("expected keys to be kept apart, got %v %v", found, err)
+	}
+	if _, err = Open(path); err == nil {
+		t.Fatalf("expected a second Open of a database in use to fail")
+	}
+
+	if err = store.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer store.Close()
+	if found, err := store.Load("network/mainnet", &s); !found || err != nil || s.Height != 42 {
+		t.Fatalf("expected the saved value after reopening, got %+v %v %v", s, found, err)
+	}
+}
@@ -0,0 +1,20 @@
+package state
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+type (
+	// Store keeps penpal's state across restarts. Values are saved and
+	// loaded as JSON under a key such as "network/mainnet". Load reports
+	// false when nothing was saved under the key.
+	Store interface {
+		Load(key string, v interface{}) (bool, error)
+		Save(key string, v interface{}) error
+	}
+
+	// BoltStore keeps every key in an embedded bbolt database file.
+	BoltStore struct {
+		db *bolt.DB
+	}
+)